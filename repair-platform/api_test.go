@@ -2,34 +2,97 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"image"
+	"image/color"
+	"image/png"
 	"math/rand"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"repair-platform/apperror"
+	"repair-platform/config"
+	"repair-platform/controllers"
 	"repair-platform/database"
+	"repair-platform/frontmatter"
+	"repair-platform/i18n"
+	"repair-platform/migrations"
+	"repair-platform/models"
 	"repair-platform/routes"
 	"repair-platform/service"
+	"repair-platform/storage"
+	"strings"
 	"testing"
+	"time"
 )
 
 var testRouter *gin.Engine
 
+// testDB 测试使用的数据库，每次 setupTest 时重新连接
+var testDB *gorm.DB
+
+// testStorage 测试使用的内存上传存储，每次 setupTest 时重建
+var testStorage *storage.Memory
+
+// stubEmailService 测试用的邮件服务，不会真正发信
+type stubEmailService struct{}
+
+func (stubEmailService) SendVerificationCode(ctx context.Context, to string, locale i18n.Locale) error {
+	return nil
+}
+
+func (stubEmailService) VerifyVerificationCode(ctx context.Context, email string, code string) bool {
+	return true
+}
+
+func (stubEmailService) SendMail(to string, subject string, body string) error { return nil }
+
+// testConfig 返回测试使用的配置
+// 默认使用 SQLite 内存数据库，可通过 TEST_DATABASE_DRIVER 和 TEST_DATABASE_DSN 指定其他数据库
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "repair_platform_test_secret"
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.DSN = "file::memory:?cache=shared"
+	// 上传的文件写入临时目录，不污染仓库中的 uploads
+	cfg.Upload.Dir = filepath.Join(os.TempDir(), "repair-platform-test", "uploads")
+	cfg.Upload.MarkdownDir = filepath.Join(os.TempDir(), "repair-platform-test", "markdown")
+	cfg.Storage.Backend = config.StorageMemory
+	if driver := os.Getenv("TEST_DATABASE_DRIVER"); driver != "" {
+		cfg.Database.Driver = driver
+		cfg.Database.DSN = os.Getenv("TEST_DATABASE_DSN")
+	}
+	return cfg
+}
+
 // setupTest 初始化测试环境
 func setupTest() {
+	cfg := testConfig()
+
 	// 初始化数据库
-	db, err := database.InitDB()
+	db, err := database.InitDB(cfg.Database)
 	if err != nil {
 		panic("数据库初始化失败")
 	}
+	if _, err := migrations.Up(db, 0); err != nil {
+		panic("数据库迁移失败: " + err.Error())
+	}
+	testDB = db
 
 	// 初始化 Email 服务
-	emailService := service.NewEmailService(nil)
+	var emailService service.EmailService = stubEmailService{}
 
 	// 初始化路由
-	testRouter = gin.Default()
-	routes.SetupRoutes(testRouter, db, emailService)
+	testRouter = gin.New()
+	testStorage = storage.NewMemory(storage.NewSigner(cfg.Auth.JWTSecret))
+	routes.SetupRoutes(testRouter, db, emailService, testStorage, cfg)
 
 	// 设置为测试模式
 	gin.SetMode(gin.TestMode)
@@ -166,59 +229,1041 @@ func TestLogin(t *testing.T) {
 
 func TestFileUpload(t *testing.T) {
 	setupTest()
+	token := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
 
-	// 动态生成用户数据
-	username := uniqueUsername()
-	email := uniqueEmail()
+	upload := func(title, content string, overwrite bool) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		_ = w.WriteField("folder", "testfolder")
+		if title != "" {
+			_ = w.WriteField("title", title)
+		}
+		if overwrite {
+			_ = w.WriteField("overwrite", "true")
+		}
+		fw, _ := w.CreateFormFile("file", "upload.md")
+		_, _ = fw.Write([]byte(content))
+		_ = w.Close()
 
-	// 注册用户
-	registerBody := map[string]string{
+		req := httptest.NewRequest("POST", "/api/upload", &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		testRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 标题来自 front matter
+	title := "testfile_" + uniqueUsername()
+	content := "---\ntitle: " + title + "\ndate: 2024-07-10\ntags: [go, blog]\ndraft: true\ncover: https://example.com/a.png\n---\n\nHello front matter.\n"
+	resp := upload("", content, false)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d: %s", http.StatusOK, resp.Code, resp.Body.String())
+	}
+	var uploaded struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &uploaded); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	post := uploaded.Post
+	if post.Title != title || post.Status != models.PostDraft || len(post.Tags) != 2 ||
+		post.Cover != "https://example.com/a.png" || post.Body != "Hello front matter.\n" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+
+	// 读取文件时返回正文和元数据
+	resp = performRequest("GET", "/api/markdown/"+url.PathEscape(title+".md")+"?folder=testfolder", nil, token)
+	var md struct {
+		Content string           `json:"content"`
+		Meta    frontmatter.Meta `json:"meta"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &md); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if md.Content != "Hello front matter.\n" || md.Meta.Title != title || !md.Meta.Draft {
+		t.Fatalf("unexpected markdown response: %s", resp.Body.String())
+	}
+
+	// 草稿文件对普通用户不可见
+	userToken := registerAndLogin(t, "")
+	for _, folder := range []string{"testfolder", "testfolder/", "./testfolder", "other/../testfolder"} {
+		if resp := performRequest("GET", "/api/markdown/"+url.PathEscape(title+".md")+"?folder="+url.QueryEscape(folder), nil, userToken); resp.Code != http.StatusNotFound {
+			t.Fatalf("expected draft file to be hidden in %q, got %d", folder, resp.Code)
+		}
+	}
+	resp = performRequest("GET", "/api/markdown/files/testfolder", nil, userToken)
+	if resp.Code != http.StatusOK || strings.Contains(resp.Body.String(), title) {
+		t.Fatalf("expected draft file to be omitted from list, got %d %s", resp.Code, resp.Body.String())
+	}
+
+	// 同名文件不能重复上传，front matter 不合法时返回字段错误
+	if resp := upload(title, "body", false); resp.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate file, got %d", resp.Code)
+	}
+
+	// 指定 overwrite 时覆盖文件，更新原文章并记录新版本
+	resp = upload(title, "Fixed typo.\n", true)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("overwrite failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &uploaded); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if uploaded.Post.ID != post.ID || uploaded.Post.Body != "Fixed typo.\n" || uploaded.Post.Status != models.PostDraft {
+		t.Fatalf("expected existing post to be updated, got %+v", uploaded.Post)
+	}
+	resp = performRequest("GET", fmt.Sprintf("/api/admin/posts/%d/revisions", post.ID), nil, token)
+	var revisions []models.PostRevision
+	if err := json.Unmarshal(resp.Body.Bytes(), &revisions); err != nil || len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %s", resp.Body.String())
+	}
+
+	// 修改导入文章的文件夹时文件随之移动，导入的文章不能移出文件夹
+	base := testConfig().Upload.MarkdownDir
+	moved := "testfolder/moved_" + uniqueUsername()
+	update := map[string]interface{}{"title": title, "folder": moved, "status": models.PostDraft, "body": "Fixed typo.\n"}
+	resp = performRequest("PUT", fmt.Sprintf("/api/admin/posts/%d", post.ID), update, token)
+	if err := json.Unmarshal(resp.Body.Bytes(), &struct {
+		Data *models.Post `json:"data"`
+	}{&post}); err != nil || resp.Code != http.StatusOK || post.SourcePath != moved+"/"+title+".md" {
+		t.Fatalf("expected source to follow folder, got %d %s", resp.Code, resp.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(base, filepath.FromSlash(post.SourcePath))); err != nil {
+		t.Fatalf("expected file to be moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "testfolder", title+".md")); !os.IsNotExist(err) {
+		t.Fatalf("expected old file to be gone, got %v", err)
+	}
+	update["folder"] = ""
+	if resp := performRequest("PUT", fmt.Sprintf("/api/admin/posts/%d", post.ID), update, token); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 when moving imported post out of folders, got %d", resp.Code)
+	}
+
+	resp = upload("bad_"+title, "---\ndate: someday\n---\nbody", false)
+	var body apperror.Response
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	if resp.Code != http.StatusBadRequest || body.Error.Code != apperror.CodeFrontMatterInvalid ||
+		len(body.Error.Details) != 1 || body.Error.Details[0].Field != "date" {
+		t.Fatalf("expected front matter validation error, got %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	setupTest()
+
+	for _, path := range []string{"/healthz", "/readyz", "/version"} {
+		resp := performRequest("GET", path, nil, "")
+		if resp.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d but got %d: %s", path, http.StatusOK, resp.Code, resp.Body.String())
+		}
+	}
+
+	resp := performRequest("GET", "/version", nil, "")
+	var info map[string]string
+	if err := json.Unmarshal(resp.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if info["version"] == "" || info["commit"] == "" {
+		t.Fatalf("version info incomplete: %v", info)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	setupTest()
+
+	performRequest("GET", "/healthz", nil, "")
+	resp := performRequest("GET", "/metrics", nil, "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d", http.StatusOK, resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), `http_requests_total{method="GET",route="/healthz",status="200"}`) {
+		t.Fatalf("metrics output missing request counter for /healthz")
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	setupTest()
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("X-Request-ID", "client-supplied-id")
+	rec := httptest.NewRecorder()
+	testRouter.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "client-supplied-id" {
+		t.Fatalf("expected request ID to be propagated, got %q", got)
+	}
+
+	resp := performRequest("GET", "/healthz", nil, "")
+	if len(resp.Header().Get("X-Request-ID")) != 32 {
+		t.Fatalf("expected generated request ID, got %q", resp.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	setupTest()
+
+	resp := performRequest("POST", "/api/register", map[string]string{"username": uniqueUsername(), "email": "not-an-email"}, "")
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d but got %d", http.StatusBadRequest, resp.Code)
+	}
+	var body apperror.Response
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if body.Error.Code != apperror.CodeValidationFailed || body.Error.RequestID == "" {
+		t.Fatalf("unexpected error body: %+v", body.Error)
+	}
+	fields := map[string]string{}
+	for _, d := range body.Error.Details {
+		fields[d.Field] = d.Rule
+	}
+	if fields["email"] != "email" || fields["password"] != "required" {
+		t.Fatalf("unexpected validation details: %+v", body.Error.Details)
+	}
+
+	resp = performRequest("GET", "/api/admin/repair_requests", nil, "")
+	body = apperror.Response{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Code != http.StatusUnauthorized || body.Error.Code != apperror.CodeAuthTokenMissing {
+		t.Fatalf("expected AUTH_TOKEN_MISSING, got %d %+v", resp.Code, body.Error)
+	}
+
+	resp = performRequest("GET", "/no/such/route", nil, "")
+	body = apperror.Response{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Code != http.StatusNotFound || body.Error.Code != apperror.CodeNotFound {
+		t.Fatalf("expected NOT_FOUND, got %d %+v", resp.Code, body.Error)
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	setupTest()
+
+	login := func(acceptLanguage string) apperror.Response {
+		reqBody, _ := json.Marshal(map[string]string{"username": "nobody_" + uniqueUsername(), "password": "password123"})
+		req := httptest.NewRequest("POST", "/api/login", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		testRouter.ServeHTTP(rec, req)
+
+		var body apperror.Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return body
+	}
+
+	if body := login("en-US,en;q=0.9"); body.Error.Message != "Invalid username or password" {
+		t.Fatalf("expected English message, got %q", body.Error.Message)
+	}
+	if body := login("zh-CN"); body.Error.Message != "用户名或密码无效" {
+		t.Fatalf("expected Chinese message, got %q", body.Error.Message)
+	}
+}
+
+// registerAndLogin 注册并验证一个新用户，返回登录令牌；inviteCode 正确时为管理员
+func registerAndLogin(t *testing.T, inviteCode string) string {
+	t.Helper()
+	username, email := uniqueUsername(), uniqueEmail()
+	resp := performRequest("POST", "/api/register", map[string]string{
 		"username":    username,
 		"email":       email,
 		"password":    "password123",
-		"invite_code": "",
+		"invite_code": inviteCode,
+	}, "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Register failed, status: %d, body: %s", resp.Code, resp.Body.String())
 	}
-	registerResp := performRequest("POST", "/api/register", registerBody, "")
-	if registerResp.Code != http.StatusOK {
-		t.Fatalf("Register failed, status: %d", registerResp.Code)
+	resp = performRequest("POST", "/api/verify_email", map[string]string{"email": email, "code": "123456"}, "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Verify email failed, status: %d", resp.Code)
+	}
+	resp = performRequest("POST", "/api/login", map[string]string{"username": username, "password": "password123"}, "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Login failed, status: %d", resp.Code)
 	}
 
-	// 模拟邮箱验证
-	verifyBody := map[string]string{
-		"email": email,
-		"code":  "123456",
+	var body struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
 	}
-	verifyResp := performRequest("POST", "/api/verify_email", verifyBody, "")
-	if verifyResp.Code != http.StatusOK {
-		t.Fatalf("Verify email failed, status: %d", verifyResp.Code)
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
 	}
+	return body.Data.Token
+}
 
-	// 登录用户
-	loginBody := map[string]string{
-		"username": username,
-		"password": "password123",
+func TestPostCRUD(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+
+	type postResponse struct {
+		Data models.Post `json:"data"`
 	}
-	loginResp := performRequest("POST", "/api/login", loginBody, "")
-	if loginResp.Code != http.StatusOK {
-		t.Fatalf("Login failed, status: %d", loginResp.Code)
+
+	// 普通用户不能创建文章
+	input := map[string]interface{}{
+		"title": "Hello 世界 " + uniqueUsername(),
+		"tags":  []string{"go", " go ", ""},
+		"body":  "# 标题\n\n第一段内容。\n\n第二段",
+	}
+	if resp := performRequest("POST", "/api/admin/posts", input, userToken); resp.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", resp.Code)
 	}
 
-	var loginResponseBody map[string]interface{}
-	err := json.Unmarshal(loginResp.Body.Bytes(), &loginResponseBody)
-	if err != nil {
+	resp := performRequest("POST", "/api/admin/posts", input, adminToken)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	var created postResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
+	post := created.Data
+	if post.Status != models.PostDraft || post.Summary != "第一段内容。" || len(post.Tags) != 1 || !strings.HasPrefix(post.Slug, "hello-世界-") {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
 
-	token := loginResponseBody["data"].(map[string]interface{})["token"].(string)
+	// 草稿对普通用户不可见
+	if resp := performRequest("GET", path, nil, userToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected draft to be hidden, got %d", resp.Code)
+	}
 
-	// 测试上传文件
-	body := map[string]string{
-		"title":  "testfile",
-		"folder": "testfolder",
+	// 发布后可见，slug 不能与其他文章重复
+	input["status"] = models.PostPublished
+	resp = performRequest("PUT", fmt.Sprintf("/api/admin/posts/%d", post.ID), input, adminToken)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("update post failed, status: %d, body: %s", resp.Code, resp.Body.String())
 	}
-	resp := performRequest("POST", "/api/upload", body, token)
+	resp = performRequest("GET", path+"?format=html", nil, userToken)
 	if resp.Code != http.StatusOK {
-		t.Fatalf("Expected status %d but got %d", http.StatusOK, resp.Code)
+		t.Fatalf("expected published post to be visible, got %d", resp.Code)
+	}
+	var detail controllers.PostDetail
+	if err := json.Unmarshal(resp.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if detail.Rendered == nil || !strings.Contains(detail.Rendered.HTML, `<h1 id="标题">标题</h1>`) || len(detail.Rendered.TOC) != 1 {
+		t.Fatalf("expected rendered HTML, got %s", resp.Body.String())
+	}
+	resp = performRequest("POST", "/api/admin/posts", map[string]interface{}{"title": "another", "slug": post.Slug}, adminToken)
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("expected slug conflict, got %d", resp.Code)
+	}
+
+	var list controllers.PostList
+	resp = performRequest("GET", "/api/posts?tag=go&page_size=1", nil, userToken)
+	if err := json.Unmarshal(resp.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if list.Total < 1 || len(list.Posts) != 1 || list.Posts[0].Body != "" {
+		t.Fatalf("unexpected post list: %+v", list)
+	}
+
+	if resp := performRequest("DELETE", fmt.Sprintf("/api/admin/posts/%d", post.ID), nil, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("delete post failed, status: %d", resp.Code)
+	}
+	if resp := performRequest("GET", path, nil, adminToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected deleted post to be gone, got %d", resp.Code)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+
+	keyword := "检索" + uniqueUsername()
+	for _, status := range []string{models.PostPublished, models.PostDraft} {
+		input := map[string]interface{}{"title": keyword + " " + status, "status": status, "body": "正文"}
+		if resp := performRequest("POST", "/api/admin/posts", input, adminToken); resp.Code != http.StatusCreated {
+			t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+		}
+	}
+
+	search := func(token, query string) controllers.SearchResponse {
+		t.Helper()
+		resp := performRequest("GET", "/api/search?q="+url.QueryEscape(query), nil, token)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("search failed, status: %d, body: %s", resp.Code, resp.Body.String())
+		}
+		var result controllers.SearchResponse
+		if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return result
+	}
+
+	// 普通用户看不到草稿
+	if result := search(userToken, keyword); result.Total != 1 || !strings.Contains(result.Hits[0].Title, "<mark>") {
+		t.Fatalf("expected only published post with highlight, got %+v", result)
+	}
+	if result := search(adminToken, keyword); result.Total != 2 {
+		t.Fatalf("expected admin to see drafts, got %+v", result)
+	}
+
+	if resp := performRequest("GET", "/api/search?q=+", nil, userToken); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty query, got %d", resp.Code)
+	}
+}
+
+func TestPostPublishWorkflow(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+
+	resp := performRequest("POST", "/api/admin/posts", map[string]interface{}{"title": "学期指南 " + uniqueUsername(), "body": "内容"}, adminToken)
+	var created struct {
+		Data models.Post `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil || resp.Code != http.StatusCreated {
+		t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	admin := fmt.Sprintf("/api/admin/posts/%d", created.Data.ID)
+	public := fmt.Sprintf("/api/posts/%d", created.Data.ID)
+
+	// 预览链接可以查看草稿，撤销后失效；持有链接的人看不到文件路径
+	if err := testDB.Model(&models.Post{}).Where("id = ?", created.Data.ID).UpdateColumn("source_path", "guides/preview.md").Error; err != nil {
+		t.Fatal(err)
+	}
+	resp = performRequest("POST", admin+"/preview", nil, adminToken)
+	var preview struct {
+		Data controllers.PreviewLink `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &preview); err != nil || resp.Code != http.StatusOK {
+		t.Fatalf("create preview failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("GET", preview.Data.URL, nil, userToken); resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `"status":"draft"`) {
+		t.Fatalf("expected preview to render draft, got %d %s", resp.Code, resp.Body.String())
+	} else if strings.Contains(resp.Body.String(), "preview.md") {
+		t.Fatalf("preview should not expose the source path: %s", resp.Body.String())
+	}
+	performRequest("DELETE", admin+"/preview", nil, adminToken)
+	if resp := performRequest("GET", preview.Data.URL, nil, userToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected revoked preview to be gone, got %d", resp.Code)
+	}
+
+	// 定时发布时间必须在将来
+	past := map[string]interface{}{"publish_at": time.Now().Add(-time.Hour)}
+	if resp := performRequest("POST", admin+"/publish", past, adminToken); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for past publish_at, got %d", resp.Code)
+	}
+	future := map[string]interface{}{"publish_at": time.Now().Add(time.Hour)}
+	resp = performRequest("POST", admin+"/publish", future, adminToken)
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `"status":"scheduled"`) {
+		t.Fatalf("schedule failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("GET", public, nil, userToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected scheduled post to be hidden, got %d", resp.Code)
+	}
+
+	// 立即发布后可见，撤回和归档后不可见
+	if resp := performRequest("POST", admin+"/publish", nil, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("publish failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("GET", public, nil, userToken); resp.Code != http.StatusOK {
+		t.Fatalf("expected published post to be visible, got %d", resp.Code)
+	}
+	for _, action := range []string{"/unpublish", "/archive"} {
+		if resp := performRequest("POST", admin+action, nil, adminToken); resp.Code != http.StatusOK {
+			t.Fatalf("%s failed, status: %d", action, resp.Code)
+		}
+		if resp := performRequest("GET", public, nil, userToken); resp.Code != http.StatusNotFound {
+			t.Fatalf("expected post to be hidden after %s, got %d", action, resp.Code)
+		}
+	}
+}
+
+func TestPostRevisions(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+
+	input := map[string]interface{}{"title": "版本 " + uniqueUsername(), "body": "第一行\n第二行\n第三行\n"}
+	resp := performRequest("POST", "/api/admin/posts", input, adminToken)
+	var created struct {
+		Data models.Post `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil || resp.Code != http.StatusCreated {
+		t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	path := fmt.Sprintf("/api/admin/posts/%d", created.Data.ID)
+
+	// 内容不变的保存不产生新版本
+	input["body"] = "第一行\n第二行已修改\n第三行\n"
+	for i := 0; i < 2; i++ {
+		if resp := performRequest("PUT", path, input, adminToken); resp.Code != http.StatusOK {
+			t.Fatalf("update post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+		}
+	}
+	resp = performRequest("GET", path+"/revisions", nil, adminToken)
+	var revisions []models.PostRevision
+	if err := json.Unmarshal(resp.Body.Bytes(), &revisions); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 2 || revisions[0].Body != "" || revisions[0].Author == "" {
+		t.Fatalf("unexpected revisions: %s", resp.Body.String())
+	}
+
+	resp = performRequest("GET", path+"/diff?format=unified", nil, adminToken)
+	want := "@@ -1,3 +1,3 @@\n 第一行\n-第二行\n+第二行已修改\n 第三行\n"
+	if resp.Code != http.StatusOK || resp.Body.String() != want {
+		t.Fatalf("unexpected diff, status: %d, body: %q", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("GET", path+"/diff?from=9", nil, adminToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing revision, got %d", resp.Code)
+	}
+
+	// 回滚恢复内容并记录为新版本
+	resp = performRequest("POST", path+"/revisions/1/rollback", nil, adminToken)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("rollback failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	resp = performRequest("GET", path+"/revisions/3", nil, adminToken)
+	var latest models.PostRevision
+	if err := json.Unmarshal(resp.Body.Bytes(), &latest); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if latest.RollbackOf != 1 || latest.Body != "第一行\n第二行\n第三行\n" {
+		t.Fatalf("unexpected rollback revision: %s", resp.Body.String())
+	}
+}
+
+func TestPublicPostsAndFeeds(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	folder := "public_" + uniqueUsername()
+
+	create := func(title, status string) models.Post {
+		input := map[string]interface{}{"title": title, "folder": folder, "status": status, "body": "**维修**步骤"}
+		resp := performRequest("POST", "/api/admin/posts", input, adminToken)
+		var created struct {
+			Data models.Post `json:"data"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil || resp.Code != http.StatusCreated {
+			t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+		}
+		return created.Data
+	}
+	published := create("公开指南 "+uniqueUsername(), models.PostPublished)
+	draft := create("草稿指南 "+uniqueUsername(), models.PostDraft)
+
+	// 不需要登录即可查看已发布的文章，草稿不可见
+	resp := performRequest("GET", "/api/public/posts/"+url.PathEscape(published.Slug)+"?format=html", nil, "")
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), "strong") {
+		t.Fatalf("expected published post to be public, got %d %s", resp.Code, resp.Body.String())
+	}
+	if resp.Header().Get("Cache-Control") == "" {
+		t.Fatalf("expected Cache-Control on public response")
+	}
+	if resp := performRequest("GET", "/api/public/posts/"+url.PathEscape(draft.Slug), nil, ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected draft to be private, got %d", resp.Code)
+	}
+	resp = performRequest("GET", "/api/public/posts?folder="+folder+"&status=draft", nil, "")
+	var list controllers.PostList
+	if err := json.Unmarshal(resp.Body.Bytes(), &list); err != nil || resp.Code != http.StatusOK {
+		t.Fatalf("list public posts failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if list.Total != 1 || list.Posts[0].ID != published.ID {
+		t.Fatalf("expected only the published post, got %+v", list)
+	}
+
+	// 从 Markdown 导入的文章不暴露服务器上的文件路径
+	if err := testDB.Model(&models.Post{}).Where("id = ?", published.ID).Update("source_path", folder+"/guide.md").Error; err != nil {
+		t.Fatal(err)
+	}
+	resp = performRequest("GET", "/api/public/posts?folder="+folder, nil, "")
+	if resp.Code != http.StatusOK || strings.Contains(resp.Body.String(), "guide.md") {
+		t.Fatalf("public list should not expose source path, got %s", resp.Body.String())
+	}
+
+	// 订阅源和站点地图只包含已发布的文章
+	site := testConfig().Site
+	for _, path := range []string{"/rss.xml", "/atom.xml", "/api/public/folders/" + folder + "/rss.xml", "/api/public/folders/" + folder + "/atom.xml", "/sitemap.xml"} {
+		resp := performRequest("GET", path, nil, "")
+		body := resp.Body.String()
+		if resp.Code != http.StatusOK || !strings.Contains(resp.Header().Get("Content-Type"), "xml") {
+			t.Fatalf("%s failed, status: %d, body: %s", path, resp.Code, body)
+		}
+		if !strings.Contains(body, site.BaseURL+site.PostPath+url.PathEscape(published.Slug)) {
+			t.Fatalf("%s should link the published post: %s", path, body)
+		}
+		if strings.Contains(body, url.PathEscape(draft.Slug)) {
+			t.Fatalf("%s should not include drafts: %s", path, body)
+		}
+	}
+	if resp := performRequest("GET", "/api/public/folders/bad-name/rss.xml", nil, ""); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid folder, got %d", resp.Code)
+	}
+}
+
+func TestCategoriesAndTags(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	root := "cat_" + uniqueUsername()
+	tag := "tag_" + uniqueUsername()
+
+	if resp := performRequest("PUT", "/api/admin/categories", map[string]interface{}{"path": root + "/phone", "name": "手机维修", "sort": 1}, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("save category failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("PUT", "/api/admin/categories", map[string]interface{}{"path": root + "/../x"}, adminToken); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid path, got %d", resp.Code)
+	}
+	input := map[string]interface{}{"title": "换屏 " + uniqueUsername(), "folder": root + "/phone", "tags": []string{tag}, "status": "published", "body": "步骤"}
+	resp := performRequest("POST", "/api/admin/posts", input, adminToken)
+	var created struct {
+		Data models.Post `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil || resp.Code != http.StatusCreated {
+		t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+
+	// 分类页面包含子分类的文章，显示名来自分类记录
+	resp = performRequest("GET", "/api/public/categories/"+root, nil, "")
+	var page controllers.CategoryPage
+	if err := json.Unmarshal(resp.Body.Bytes(), &page); err != nil || resp.Code != http.StatusOK {
+		t.Fatalf("category page failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if page.Total != 1 || page.Category.Total != 1 || len(page.Category.Children) != 1 || page.Category.Children[0].Name != "手机维修" {
+		t.Fatalf("unexpected category page: %s", resp.Body.String())
+	}
+	if resp := performRequest("GET", "/api/public/categories", nil, ""); !strings.Contains(resp.Body.String(), `"path":"`+root+`"`) {
+		t.Fatalf("expected category in tree: %s", resp.Body.String())
+	}
+
+	// 标签列表和标签页面
+	if resp := performRequest("GET", "/api/public/tags", nil, ""); !strings.Contains(resp.Body.String(), `{"tag":"`+tag+`","count":1}`) {
+		t.Fatalf("expected tag count: %s", resp.Body.String())
+	}
+	resp = performRequest("GET", "/api/public/tags/"+tag, nil, "")
+	var tagPage controllers.TagPage
+	if err := json.Unmarshal(resp.Body.Bytes(), &tagPage); err != nil || tagPage.Total != 1 || tagPage.Posts[0].ID != created.Data.ID {
+		t.Fatalf("unexpected tag page, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+
+	// 含有 JSON 中会被转义的字符的标签同样可以筛选
+	special := `R&D <"` + uniqueUsername() + `">`
+	input2 := map[string]interface{}{"title": "特殊标签 " + uniqueUsername(), "tags": []string{special}, "status": "published", "body": "内容"}
+	if resp := performRequest("POST", "/api/admin/posts", input2, adminToken); resp.Code != http.StatusCreated {
+		t.Fatalf("create post failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	resp = performRequest("GET", "/api/public/tags/"+url.PathEscape(special), nil, "")
+	if err := json.Unmarshal(resp.Body.Bytes(), &tagPage); err != nil || tagPage.Total != 1 {
+		t.Fatalf("unexpected special tag page, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	resp = performRequest("GET", "/api/public/posts?tag="+url.QueryEscape(special), nil, "")
+	if !strings.Contains(resp.Body.String(), `"total":1`) {
+		t.Fatalf("expected tag filter to match, got %d %s", resp.Code, resp.Body.String())
+	}
+
+	// 移动后旧路径重定向，文章 slug 不变
+	moved := root + "_new/devices"
+	resp = performRequest("POST", "/api/admin/categories/move", map[string]string{"from": root, "to": moved}, adminToken)
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `"posts":1`) {
+		t.Fatalf("move failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("POST", "/api/admin/categories/move", map[string]string{"from": root, "to": moved}, adminToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when moving a missing category, got %d", resp.Code)
+	}
+	resp = performRequest("GET", "/api/public/categories/"+root+"/phone?page=1", nil, "")
+	if resp.Code != http.StatusMovedPermanently || resp.Header().Get("Location") != "/api/public/categories/"+moved+"/phone?page=1" {
+		t.Fatalf("expected redirect, got %d %q", resp.Code, resp.Header().Get("Location"))
+	}
+	resp = performRequest("GET", "/api/public/folders/"+root+"/phone/atom.xml", nil, "")
+	if resp.Code != http.StatusMovedPermanently || resp.Header().Get("Location") != "/api/public/folders/"+moved+"/phone/atom.xml" {
+		t.Fatalf("expected feed redirect, got %d %q", resp.Code, resp.Header().Get("Location"))
+	}
+	if resp := performRequest("GET", "/api/public/folders/"+moved+"/phone/atom.xml", nil, ""); resp.Code != http.StatusOK {
+		t.Fatalf("nested folder feed failed, status: %d", resp.Code)
+	}
+	if resp := performRequest("GET", "/api/public/posts/"+url.PathEscape(created.Data.Slug), nil, ""); resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), moved+"/phone") {
+		t.Fatalf("expected post to keep its url in the new folder, got %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestFolderAndFileOperations(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+	base := testConfig().Upload.MarkdownDir
+	folder := "ops_" + uniqueUsername()
+	if err := os.MkdirAll(filepath.Join(base, folder, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, folder, "a.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同名前缀的兄弟目录和指向外部的符号链接都不能访问
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(base, folder, "link")); err != nil {
+		t.Fatal(err)
+	}
+	sibling := base + "_" + folder
+	if err := os.MkdirAll(sibling, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sibling)
+	if err := os.WriteFile(filepath.Join(sibling, "secret.md"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{folder + "/link", "../" + filepath.Base(sibling), "../.."} {
+		resp := performRequest("GET", "/api/markdown/secret.md?folder="+url.QueryEscape(q), nil, adminToken)
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for folder %q, got %d %s", q, resp.Code, resp.Body.String())
+		}
+	}
+	if err := os.Remove(filepath.Join(base, folder, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 改名并移动文件，只有管理员可以操作
+	move := map[string]string{"folder": folder + "/sub", "file": "b.md"}
+	if resp := performRequest("PATCH", "/api/markdown/a.md?folder="+folder, move, userToken); resp.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", resp.Code)
+	}
+	if resp := performRequest("PATCH", "/api/markdown/a.md?folder="+folder, map[string]string{"file": "../b.md"}, adminToken); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for traversal in file name, got %d", resp.Code)
+	}
+	if resp := performRequest("PATCH", "/api/markdown/a.md?folder="+folder, move, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("move file failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(base, folder, "sub", "b.md")); err != nil {
+		t.Fatalf("expected moved file: %v", err)
+	}
+
+	// 非空文件夹需要 recursive 和 confirm 才能删除
+	if resp := performRequest("DELETE", "/api/upload/folders?folder="+folder, nil, adminToken); resp.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for non-empty folder, got %d", resp.Code)
+	}
+	if resp := performRequest("DELETE", "/api/upload/folders?recursive=true&folder="+folder, nil, adminToken); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without confirm, got %d", resp.Code)
+	}
+
+	// 文件夹改名后删除文件和文件夹
+	renamed := folder + "_renamed"
+	if resp := performRequest("PATCH", "/api/upload/folders", map[string]string{"folder": folder, "name": renamed}, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("rename folder failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("DELETE", "/api/markdown/b.md?folder="+renamed+"/sub", nil, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("delete file failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if resp := performRequest("DELETE", "/api/markdown/b.md?folder="+renamed+"/sub", nil, adminToken); resp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for deleted file, got %d", resp.Code)
+	}
+	path := "/api/upload/folders?recursive=true&folder=" + renamed + "&confirm=" + renamed
+	if resp := performRequest("DELETE", path, nil, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("delete folder failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(base, renamed)); !os.IsNotExist(err) {
+		t.Fatalf("expected folder to be removed, got %v", err)
+	}
+}
+
+func TestRepairAttachment(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+
+	// 附件写入上传存储的根目录
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	description := "附件" + uniqueUsername()
+	_ = w.WriteField("description", description)
+	fw, _ := w.CreateFormFile("file", "photo.png")
+	_, _ = fw.Write(testPNG(t, 600, 300))
+	_ = w.Close()
+	req := httptest.NewRequest("POST", "/api/repair_requests", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	resp := httptest.NewRecorder()
+	testRouter.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("submit repair failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+
+	resp = performRequest("GET", "/api/admin/repair_requests", nil, adminToken)
+	var requests []models.RepairRequest
+	if err := json.Unmarshal(resp.Body.Bytes(), &requests); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	var request *models.RepairRequest
+	for i := range requests {
+		if requests[i].Description == description {
+			request = &requests[i]
+		}
+	}
+	if request == nil || !strings.HasSuffix(request.ImageURL, ".png") || strings.Contains(request.ImageURL, "/") {
+		t.Fatalf("unexpected repair request: %+v", request)
+	}
+	if _, err := testStorage.Stat(context.Background(), request.ImageURL); err != nil {
+		t.Fatalf("expected attachment in storage: %v", err)
+	}
+
+	// 图片记录包含尺寸和派生尺寸，派生尺寸与原图一起保存
+	var image models.Image
+	if err := testDB.Where("object_key = ?", request.ImageURL).First(&image).Error; err != nil {
+		t.Fatalf("expected image record: %v", err)
+	}
+	if image.Source != models.ImageSourceRepair || image.Width != 600 || image.Height != 300 || len(image.Variants) != 4 {
+		t.Fatalf("unexpected image record: %+v", image)
+	}
+	for _, v := range image.Variants {
+		if _, err := testStorage.Stat(context.Background(), v.Key); err != nil || v.Width > 1024 {
+			t.Fatalf("variant %+v: %v", v, err)
+		}
+	}
+
+	// 管理员查看附件时重定向到签名下载地址，地址不需要登录
+	resp = performRequest("GET", fmt.Sprintf("/api/admin/repair_requests/%d/attachment", request.ID), nil, adminToken)
+	location := resp.Header().Get("Location")
+	if resp.Code != http.StatusFound || !strings.HasPrefix(location, storage.SignedPathPrefix) {
+		t.Fatalf("expected redirect to signed url, got %d %q", resp.Code, location)
+	}
+	resp = performRequest("GET", location, nil, "")
+	if _, err := png.Decode(resp.Body); resp.Code != http.StatusOK || err != nil || resp.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("download failed, status: %d, type: %s, err: %v", resp.Code, resp.Header().Get("Content-Type"), err)
+	}
+	resp = performRequest("GET", strings.Replace(location, "signature=", "signature=0", 1), nil, "")
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for tampered signature, got %d", resp.Code)
+	}
+}
+
+// testPNG 生成 w×h 的 PNG 图片
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: uint8(x), A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// uploadImage 通过 /api/upload/image 上传图片
+func uploadImage(t *testing.T, token, filename string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, _ := w.CreateFormFile("image", filename)
+	_, _ = fw.Write(data)
+	_ = w.Close()
+	req := httptest.NewRequest("POST", "/api/upload/image", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	testRouter.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestLocalImageHost(t *testing.T) {
+	setupTest()
+	token := registerAndLogin(t, "")
+
+	data := testPNG(t, 800, 400)
+	resp := uploadImage(t, token, "photo.png", data)
+	var uploaded struct {
+		ImageURL  string `json:"image_url"`
+		DeleteURL string `json:"delete_url"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		Variants  []struct {
+			Name        string `json:"name"`
+			URL         string `json:"url"`
+			ContentType string `json:"content_type"`
+			Width       int    `json:"width"`
+			Height      int    `json:"height"`
+		} `json:"variants"`
+	}
+	if resp.Code != http.StatusOK || json.Unmarshal(resp.Body.Bytes(), &uploaded) != nil {
+		t.Fatalf("upload failed, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+	imageURL := uploaded.ImageURL
+	if !strings.HasPrefix(imageURL, "/media/") || !strings.HasSuffix(imageURL, ".png") || uploaded.DeleteURL != "" || uploaded.Width != 800 || uploaded.Height != 400 {
+		t.Fatalf("unexpected upload response: %+v", uploaded)
+	}
+
+	// 默认配置生成 thumbnail 和 medium 两种尺寸，各有 PNG 和 WebP 版本
+	if len(uploaded.Variants) != 4 {
+		t.Fatalf("expected 4 variants, got %+v", uploaded.Variants)
+	}
+	thumb := uploaded.Variants[1]
+	if thumb.Name != "thumbnail" || thumb.ContentType != "image/webp" || thumb.Width != 320 || thumb.Height != 160 {
+		t.Fatalf("unexpected thumbnail: %+v", thumb)
+	}
+	resp = performRequest("GET", thumb.URL, nil, "")
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "image/webp" {
+		t.Fatalf("get webp variant failed, status: %d", resp.Code)
+	}
+
+	// 同一张图片的地址不变
+	resp = uploadImage(t, token, "again.png", data)
+	if !strings.Contains(resp.Body.String(), imageURL) {
+		t.Fatalf("expected same url for same content, got %s", resp.Body.String())
+	}
+
+	resp = performRequest("GET", imageURL, nil, "")
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("get media failed, status: %d, type: %s", resp.Code, resp.Header().Get("Content-Type"))
+	}
+	if img, err := png.Decode(bytes.NewReader(resp.Body.Bytes())); err != nil || img.Bounds().Dx() != 800 {
+		t.Fatalf("unexpected media content: %v", err)
+	}
+	if cc := resp.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+		t.Fatalf("expected long-lived cache, got %q", cc)
+	}
+	req := httptest.NewRequest("GET", imageURL, nil)
+	req.Header.Set("If-None-Match", resp.Header().Get("ETag"))
+	resp = httptest.NewRecorder()
+	testRouter.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotModified || resp.Body.Len() != 0 {
+		t.Fatalf("expected 304, got %d", resp.Code)
+	}
+
+	// 扩展名与内容不符的文件被拒绝
+	if resp := uploadImage(t, token, "fake.png", []byte("<html>not an image</html>")); resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for fake image, got %d", resp.Code)
+	}
+
+	// /media/ 只公开图床的图片，上传存储中的其他对象不可访问
+	_ = testStorage.Put(context.Background(), "attachment.png", bytes.NewReader(data), int64(len(data)), "")
+	for _, p := range []string{"/media/attachment.png", "/media/../attachment.png", "/media/missing.png"} {
+		if resp := performRequest("GET", p, nil, ""); resp.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", p, resp.Code)
+		}
+	}
+}
+
+func TestDataExportImport(t *testing.T) {
+	setupTest()
+
+	post := models.Post{Title: "backup", Slug: "backup-" + uniqueUsername(), Status: models.PostDraft, PreviewToken: "token-" + uniqueUsername()}
+	image := models.Image{ObjectKey: "images/backup-" + uniqueUsername() + ".png", Source: models.ImageSourceHost, ContentType: "image/png"}
+	if err := testDB.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.Create(&image).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := exportData(testDB, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	token := post.PreviewToken
+	if err := testDB.Model(&post).UpdateColumn("preview_token", "").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.Delete(&image).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 导入后恢复预览令牌和图片记录，之后新建的记录不与导入的主键冲突
+	if err := importData(testDB, &buf); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var restored models.Post
+	if err := testDB.First(&restored, post.ID).Error; err != nil || restored.PreviewToken != token {
+		t.Fatalf("expected preview token to be restored, got %q (%v)", restored.PreviewToken, err)
+	}
+	if err := testDB.First(&models.Image{}, image.ID).Error; err != nil {
+		t.Fatalf("expected image to be restored: %v", err)
+	}
+	next := models.Image{ObjectKey: "images/next-" + uniqueUsername() + ".png", Source: models.ImageSourceHost}
+	if err := testDB.Create(&next).Error; err != nil || next.ID <= image.ID {
+		t.Fatalf("create after import: %d %v", next.ID, err)
+	}
+}
+
+func TestReuploadDeletedPost(t *testing.T) {
+	setupTest()
+	adminToken := registerAndLogin(t, testConfig().Auth.AdminInviteCode)
+	userToken := registerAndLogin(t, "")
+	folder := "reupload_" + strings.TrimPrefix(uniqueUsername(), "testuser_")
+	title := "guide"
+
+	upload := func(overwrite bool) models.Post {
+		t.Helper()
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		_ = w.WriteField("folder", folder)
+		_ = w.WriteField("title", title)
+		if overwrite {
+			_ = w.WriteField("overwrite", "true")
+		}
+		fw, _ := w.CreateFormFile("file", "upload.md")
+		_, _ = fw.Write([]byte("Published guide.\n"))
+		_ = w.Close()
+		req := httptest.NewRequest("POST", "/api/upload", &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rec := httptest.NewRecorder()
+		testRouter.ServeHTTP(rec, req)
+		var body struct {
+			Post models.Post `json:"post"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("upload failed: %d %s", rec.Code, rec.Body.String())
+		}
+		return body.Post
+	}
+	listed := func() bool {
+		t.Helper()
+		resp := performRequest("GET", "/api/markdown/files/"+folder, nil, userToken)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("list failed: %d %s", resp.Code, resp.Body.String())
+		}
+		return strings.Contains(resp.Body.String(), title+".md")
+	}
+
+	post := upload(false)
+	if post.Status != models.PostPublished || !listed() {
+		t.Fatalf("expected published file to be listed, got %+v", post)
+	}
+	if resp := performRequest("DELETE", fmt.Sprintf("/api/admin/posts/%d", post.ID), nil, adminToken); resp.Code != http.StatusOK {
+		t.Fatalf("delete failed: %d", resp.Code)
+	}
+	if listed() {
+		t.Fatal("expected file of deleted post to be hidden")
+	}
+
+	// 覆盖上传恢复原文章，不会产生指向同一文件的第二篇文章
+	restored := upload(true)
+	if restored.ID != post.ID || !listed() {
+		t.Fatalf("expected deleted post to be restored, got %+v", restored)
+	}
+	if resp := performRequest("GET", "/api/markdown/"+title+".md?folder="+folder, nil, userToken); resp.Code != http.StatusOK {
+		t.Fatalf("expected restored file to be readable, got %d", resp.Code)
+	}
+	var count int64
+	testDB.Unscoped().Model(&models.Post{}).Where("source_path = ?", folder+"/"+title+".md").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected one post for the file, got %d", count)
+	}
+
+	// 已有重复记录时，未删除的已发布文章决定文件可见
+	dup := models.Post{Title: "old", Slug: "old-" + folder, Folder: folder, SourcePath: post.SourcePath, Status: models.PostDraft}
+	if err := testDB.Create(&dup).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.Delete(&dup).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !listed() {
+		t.Fatal("expected file of live published post to stay visible")
 	}
-	t.Log("文件上传测试通过")
 }
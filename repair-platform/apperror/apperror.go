@@ -0,0 +1,178 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"repair-platform/i18n"
+)
+
+// Code 是稳定的、供客户端判断错误类型的错误码
+type Code string
+
+// 通用错误码
+const (
+	CodeInvalidInput       Code = "INVALID_INPUT"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeMethodNotAllowed   Code = "METHOD_NOT_ALLOWED"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+)
+
+// 认证和用户相关错误码
+const (
+	CodeAuthTokenMissing       Code = "AUTH_TOKEN_MISSING"
+	CodeAuthTokenInvalid       Code = "AUTH_TOKEN_INVALID"
+	CodeAuthInvalidCredentials Code = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthEmailNotVerified   Code = "AUTH_EMAIL_NOT_VERIFIED"
+	CodeAuthInvalidCode        Code = "AUTH_INVALID_CODE"
+	CodeAuthUserExists         Code = "AUTH_USER_EXISTS"
+	CodeUserNotFound           Code = "USER_NOT_FOUND"
+	CodeMailSendFailed         Code = "MAIL_SEND_FAILED"
+)
+
+// 业务相关错误码
+const (
+	CodeRepairNotFound      Code = "REPAIR_NOT_FOUND"
+	CodeFeedbackNotFound    Code = "FEEDBACK_NOT_FOUND"
+	CodeUploadTooLarge      Code = "UPLOAD_TOO_LARGE"
+	CodeUploadInvalidType   Code = "UPLOAD_INVALID_TYPE"
+	CodeUploadFailed        Code = "UPLOAD_FAILED"
+	CodeInvalidPath         Code = "INVALID_PATH"
+	CodeFolderNotFound      Code = "FOLDER_NOT_FOUND"
+	CodeFolderExists        Code = "FOLDER_EXISTS"
+	CodeFolderNotEmpty      Code = "FOLDER_NOT_EMPTY"
+	CodeFileNotFound        Code = "FILE_NOT_FOUND"
+	CodeFileExists          Code = "FILE_EXISTS"
+	CodeImageHostFailed     Code = "IMAGE_HOST_FAILED"
+	CodeMetricsTokenInvalid Code = "METRICS_TOKEN_INVALID"
+	CodePostNotFound        Code = "POST_NOT_FOUND"
+	CodePostSlugExists      Code = "POST_SLUG_EXISTS"
+	CodeFrontMatterInvalid  Code = "FRONT_MATTER_INVALID"
+	CodeRevisionNotFound    Code = "REVISION_NOT_FOUND"
+	CodeSignedURLInvalid    Code = "SIGNED_URL_INVALID"
+)
+
+// FieldError 描述单个字段的校验失败原因，Message 在返回时按请求的语言生成
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error 是 API 返回给客户端的错误，cause 只用于日志，不会返回给客户端
+// 提示信息以消息目录中的键保存，返回时按请求的语言渲染
+type Error struct {
+	Status  int
+	Code    Code
+	Key     string
+	Args    []interface{}
+	Details []FieldError
+	cause   error
+}
+
+// New 创建错误，提示信息使用消息目录中的 error.<code>
+func New(status int, code Code) *Error {
+	return &Error{Status: status, Code: code, Key: "error." + string(code)}
+}
+
+// Message 返回指定语言的提示信息
+func (e *Error) Message(l i18n.Locale) string {
+	return i18n.T(l, e.Key, e.Args...)
+}
+
+// Error 实现 error，使用默认语言
+func (e *Error) Error() string {
+	msg := string(e.Code) + ": " + e.Message(i18n.Default)
+	if e.cause != nil {
+		return msg + ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Unwrap 返回底层错误
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 按错误码比较，便于 errors.Is(err, apperror.ErrRepairNotFound)
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if errors.As(target, &t) {
+		return e.Code == t.Code
+	}
+	return false
+}
+
+// Wrap 返回附带底层错误的副本，底层错误只写入日志
+func (e *Error) Wrap(cause error) *Error {
+	cp := *e
+	cp.cause = cause
+	return &cp
+}
+
+// WithMessage 返回使用消息目录中 key 作为提示信息的副本，错误码不变
+func (e *Error) WithMessage(key string, args ...interface{}) *Error {
+	cp := *e
+	cp.Key = key
+	cp.Args = args
+	return &cp
+}
+
+// WithDetails 返回附带字段错误的副本
+func (e *Error) WithDetails(details []FieldError) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// From 将任意错误转换为 *Error，无法识别的错误视为内部错误
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// 预定义错误，使用时可以通过 Wrap/WithMessage 派生
+var (
+	ErrInvalidInput       = New(http.StatusBadRequest, CodeInvalidInput)
+	ErrValidationFailed   = New(http.StatusBadRequest, CodeValidationFailed)
+	ErrForbidden          = New(http.StatusForbidden, CodeForbidden)
+	ErrNotFound           = New(http.StatusNotFound, CodeNotFound)
+	ErrMethodNotAllowed   = New(http.StatusMethodNotAllowed, CodeMethodNotAllowed)
+	ErrInternal           = New(http.StatusInternalServerError, CodeInternal)
+	ErrServiceUnavailable = New(http.StatusServiceUnavailable, CodeServiceUnavailable)
+
+	ErrAuthTokenMissing       = New(http.StatusUnauthorized, CodeAuthTokenMissing)
+	ErrAuthTokenInvalid       = New(http.StatusUnauthorized, CodeAuthTokenInvalid)
+	ErrAuthInvalidCredentials = New(http.StatusUnauthorized, CodeAuthInvalidCredentials)
+	ErrAuthEmailNotVerified   = New(http.StatusUnauthorized, CodeAuthEmailNotVerified)
+	ErrAuthInvalidCode        = New(http.StatusUnauthorized, CodeAuthInvalidCode)
+	ErrAuthUserExists         = New(http.StatusConflict, CodeAuthUserExists)
+	ErrUserNotFound           = New(http.StatusNotFound, CodeUserNotFound)
+	ErrMailSendFailed         = New(http.StatusInternalServerError, CodeMailSendFailed)
+
+	ErrRepairNotFound      = New(http.StatusNotFound, CodeRepairNotFound)
+	ErrFeedbackNotFound    = New(http.StatusNotFound, CodeFeedbackNotFound)
+	ErrUploadTooLarge      = New(http.StatusRequestEntityTooLarge, CodeUploadTooLarge)
+	ErrUploadInvalidType   = New(http.StatusBadRequest, CodeUploadInvalidType)
+	ErrUploadFailed        = New(http.StatusInternalServerError, CodeUploadFailed)
+	ErrInvalidPath         = New(http.StatusBadRequest, CodeInvalidPath)
+	ErrFolderNotFound      = New(http.StatusNotFound, CodeFolderNotFound)
+	ErrFolderExists        = New(http.StatusConflict, CodeFolderExists)
+	ErrFolderNotEmpty      = New(http.StatusConflict, CodeFolderNotEmpty)
+	ErrFileNotFound        = New(http.StatusNotFound, CodeFileNotFound)
+	ErrFileExists          = New(http.StatusConflict, CodeFileExists)
+	ErrImageHostFailed     = New(http.StatusBadGateway, CodeImageHostFailed)
+	ErrMetricsTokenInvalid = New(http.StatusUnauthorized, CodeMetricsTokenInvalid)
+	ErrPostNotFound        = New(http.StatusNotFound, CodePostNotFound)
+	ErrPostSlugExists      = New(http.StatusConflict, CodePostSlugExists)
+	ErrFrontMatterInvalid  = New(http.StatusBadRequest, CodeFrontMatterInvalid)
+	ErrRevisionNotFound    = New(http.StatusNotFound, CodeRevisionNotFound)
+	ErrSignedURLInvalid    = New(http.StatusForbidden, CodeSignedURLInvalid)
+)
@@ -0,0 +1,45 @@
+package apperror
+
+import (
+	"repair-platform/i18n"
+	"repair-platform/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response 是所有错误响应的外层结构
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body 是错误响应的内容
+type Body struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Abort 以统一格式返回错误并中止后续处理函数，提示信息使用当前请求的语言
+// 5xx 错误会记录底层原因，客户端只能看到错误码和提示信息
+func Abort(c *gin.Context, err error) {
+	e := From(err)
+	_ = c.Error(err)
+
+	if e.Status >= 500 {
+		logging.FromContext(c).Errorw("请求处理失败", "code", e.Code, "error", err)
+	}
+
+	locale := i18n.FromContext(c)
+	c.AbortWithStatusJSON(e.Status, Response{Error: Body{
+		Code:      e.Code,
+		Message:   e.Message(locale),
+		Details:   localizeDetails(locale, e.Details),
+		RequestID: logging.RequestID(c),
+	}})
+}
+
+// BindError 将 gin 绑定错误转换为 API 错误后返回
+func BindError(c *gin.Context, err error) {
+	Abort(c, FromBinding(err))
+}
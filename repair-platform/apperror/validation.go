@@ -0,0 +1,81 @@
+package apperror
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"repair-platform/i18n"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerOnce sync.Once
+
+// RegisterValidator 让校验错误中的字段名使用 json/form 标签，而不是 Go 字段名
+func RegisterValidator() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	})
+}
+
+// FromBinding 将 ShouldBind 系列返回的错误转换为 API 错误
+// 字段校验失败时返回 VALIDATION_FAILED 及每个字段的原因，其他情况（JSON 格式错误等）返回 INVALID_INPUT
+func FromBinding(err error) *Error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return ErrInvalidInput.Wrap(err)
+	}
+
+	details := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		details = append(details, FieldError{
+			Field: fe.Field(),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	return ErrValidationFailed.WithDetails(details)
+}
+
+// localizeDetails 按语言生成字段错误的提示，不修改原切片
+func localizeDetails(l i18n.Locale, details []FieldError) []FieldError {
+	if len(details) == 0 {
+		return nil
+	}
+	out := make([]FieldError, len(details))
+	for i, d := range details {
+		d.Message = fieldMessage(l, d)
+		out[i] = d
+	}
+	return out
+}
+
+// fieldMessage 返回字段校验失败的提示
+func fieldMessage(l i18n.Locale, d FieldError) string {
+	switch d.Rule {
+	case "required", "email", "datetime", "url", "string", "boolean", "future":
+		return i18n.T(l, "validation."+d.Rule, d.Field)
+	case "min", "max", "oneof":
+		return i18n.T(l, "validation."+d.Rule, d.Field, d.Param)
+	default:
+		return i18n.T(l, "validation.default", d.Field, d.Rule)
+	}
+}
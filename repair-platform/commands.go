@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"repair-platform/config"
+	"repair-platform/database"
+	"repair-platform/migrations"
+
+	"gorm.io/gorm"
+)
+
+// commandUsage 子命令帮助信息
+const commandUsage = `用法: repair-platform [-config 路径] <命令> [参数]
+
+命令:
+  (无)                     启动 HTTP 服务
+  migrate up [N]           执行全部或前 N 个待执行迁移
+  migrate down [N]         回滚最近 N 个迁移（默认 1）
+  migrate status           查看迁移执行状态
+  migrate create <名称>    在 -dir 指定的目录下生成迁移模板（默认 migrations）
+  user create              创建用户 (-username -email -password -role -verified)
+  user set-role <用户> <角色>  修改用户角色 (user, technician, admin)
+  user verify <用户>        标记用户邮箱已验证
+  user reset-password <用户> [-password 新密码]  重置密码，未指定时随机生成
+  tokens purge             清理过期的验证码/重置令牌
+  data export [-o 文件]     导出用户、报修请求和反馈为 JSON（默认输出到标准输出）
+  data import -i <文件>     从 JSON 导入数据，主键相同的记录会被覆盖
+  posts import -author <用户>  将 Markdown 目录中尚未导入的文件导入为文章
+`
+
+// runCommand 执行命令行子命令
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "user":
+		return runUser(cfg, args[1:])
+	case "tokens":
+		return runTokens(cfg, args[1:])
+	case "data":
+		return runData(cfg, args[1:])
+	case "posts":
+		return runPosts(cfg, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(commandUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// withDB 打开配置的数据库执行 fn，结束后关闭连接
+func withDB(cfg *config.Config, fn func(db *gorm.DB) error) error {
+	db, err := database.InitDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer database.CloseDB(db)
+	return fn(db)
+}
+
+// withMigratedDB 与 withDB 相同，但要求数据库结构已是最新
+func withMigratedDB(cfg *config.Config, fn func(db *gorm.DB) error) error {
+	return withDB(cfg, func(db *gorm.DB) error {
+		if err := migrations.CheckUpToDate(db); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+// runMigrate 处理 migrate 子命令
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", "migrations", "migrate create 生成文件的目录")
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return errors.New("missing migrate action")
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch action {
+	case "up", "down":
+		steps := 0
+		if fs.NArg() > 0 {
+			n, err := strconv.Atoi(fs.Arg(0))
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step count %q", fs.Arg(0))
+			}
+			steps = n
+		}
+		return withDB(cfg, func(db *gorm.DB) error {
+			if action == "up" {
+				done, err := migrations.Up(db, steps)
+				for _, m := range done {
+					fmt.Printf("applied  %s_%s\n", m.Version, m.Name)
+				}
+				if err == nil && len(done) == 0 {
+					fmt.Println("no pending migrations")
+				}
+				return err
+			}
+			done, err := migrations.Down(db, steps)
+			for _, m := range done {
+				fmt.Printf("reverted %s_%s\n", m.Version, m.Name)
+			}
+			if err == nil && len(done) == 0 {
+				fmt.Println("no applied migrations")
+			}
+			return err
+		})
+	case "status":
+		return withDB(cfg, func(db *gorm.DB) error {
+			statuses, err := migrations.StatusOf(db)
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				state := "pending"
+				if s.Applied {
+					state = "applied " + s.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Printf("%s_%-40s %s\n", s.Version, s.Name, state)
+			}
+			return nil
+		})
+	case "create":
+		if fs.NArg() == 0 {
+			return errors.New("migrate create requires a name")
+		}
+		path, err := migrations.Create(*dir, fs.Arg(0), time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", path)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"repair-platform/config"
+	"repair-platform/models"
+	"repair-platform/service"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dataDumpVersion 导出文件格式版本
+const dataDumpVersion = 1
+
+// dataDump 是 data export/import 使用的文件格式，只包含未被软删除的记录
+type dataDump struct {
+	Version        int                       `json:"version"`
+	ExportedAt     time.Time                 `json:"exported_at"`
+	Users          []models.User             `json:"users"`
+	RepairRequests []models.RepairRequest    `json:"repair_requests"`
+	Feedback       []models.Feedback         `json:"feedback"`
+	Posts          []models.Post             `json:"posts"`
+	PostRevisions  []models.PostRevision     `json:"post_revisions"`
+	Categories     []models.Category         `json:"categories"`
+	Redirects      []models.CategoryRedirect `json:"category_redirects"`
+	Images         []models.Image            `json:"images"`
+	// PreviewTokens 按文章 ID 保存预览令牌，Post 序列化时不包含令牌，单独导出以保留已分享的预览链接
+	PreviewTokens map[uint]string `json:"post_preview_tokens,omitempty"`
+}
+
+// runUser 处理 user 子命令
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing user action")
+	}
+	action, args := args[0], args[1:]
+
+	switch action {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		username := fs.String("username", "", "用户名")
+		email := fs.String("email", "", "邮箱")
+		password := fs.String("password", "", "密码，未指定时随机生成")
+		role := fs.String("role", models.RoleUser, "角色 (user, technician, admin)")
+		verified := fs.Bool("verified", true, "是否直接标记邮箱已验证")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *username == "" || *email == "" {
+			return errors.New("-username and -email are required")
+		}
+		if !models.IsValidRole(*role) {
+			return fmt.Errorf("invalid role %q", *role)
+		}
+		return withMigratedDB(cfg, func(db *gorm.DB) error {
+			pw, generated := *password, false
+			if pw == "" {
+				pw, generated = randomPassword(), true
+			}
+			user := models.User{Username: *username, Email: *email, Role: *role, IsVerified: *verified}
+			if err := user.SetPassword(pw); err != nil {
+				return err
+			}
+			if err := db.Create(&user).Error; err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			fmt.Printf("created user %s (id=%d, role=%s)\n", user.Username, user.ID, user.Role)
+			if generated {
+				fmt.Printf("generated password: %s\n", pw)
+			}
+			return nil
+		})
+	case "set-role":
+		if len(args) != 2 {
+			return errors.New("usage: user set-role <username|email> <role>")
+		}
+		if !models.IsValidRole(args[1]) {
+			return fmt.Errorf("invalid role %q", args[1])
+		}
+		return updateUser(cfg, args[0], func(user *models.User) error {
+			user.Role = args[1]
+			fmt.Printf("user %s role set to %s\n", user.Username, user.Role)
+			return nil
+		})
+	case "verify":
+		if len(args) != 1 {
+			return errors.New("usage: user verify <username|email>")
+		}
+		return updateUser(cfg, args[0], func(user *models.User) error {
+			user.IsVerified = true
+			fmt.Printf("user %s marked as verified\n", user.Username)
+			return nil
+		})
+	case "reset-password":
+		if len(args) == 0 {
+			return errors.New("usage: user reset-password <username|email> [-password <new password>]")
+		}
+		fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+		password := fs.String("password", "", "新密码，未指定时随机生成")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return updateUser(cfg, args[0], func(user *models.User) error {
+			pw := *password
+			if pw == "" {
+				pw = randomPassword()
+				fmt.Printf("generated password: %s\n", pw)
+			}
+			if err := user.SetPassword(pw); err != nil {
+				return err
+			}
+			fmt.Printf("password of user %s has been reset\n", user.Username)
+			return nil
+		})
+	default:
+		return fmt.Errorf("unknown user action %q", action)
+	}
+}
+
+// updateUser 按用户名或邮箱查找用户，修改后保存
+func updateUser(cfg *config.Config, identifier string, fn func(user *models.User) error) error {
+	return withMigratedDB(cfg, func(db *gorm.DB) error {
+		var user models.User
+		if err := db.Where("username = ? OR email = ?", identifier, identifier).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("user %q not found", identifier)
+			}
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+		return db.Save(&user).Error
+	})
+}
+
+// runTokens 处理 tokens 子命令
+func runTokens(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "purge" {
+		return errors.New("usage: tokens purge")
+	}
+	return withMigratedDB(cfg, func(db *gorm.DB) error {
+		if err := models.DeleteExpiredTokens(db); err != nil {
+			return fmt.Errorf("failed to purge expired tokens: %w", err)
+		}
+		fmt.Println("expired tokens purged")
+		return nil
+	})
+}
+
+// runData 处理 data 子命令
+func runData(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing data action")
+	}
+	action, args := args[0], args[1:]
+
+	switch action {
+	case "export":
+		fs := flag.NewFlagSet("data export", flag.ContinueOnError)
+		output := fs.String("o", "", "输出文件，默认标准输出")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return withMigratedDB(cfg, func(db *gorm.DB) error {
+			var w io.Writer = os.Stdout
+			if *output != "" {
+				f, err := os.Create(*output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			return exportData(db, w)
+		})
+	case "import":
+		fs := flag.NewFlagSet("data import", flag.ContinueOnError)
+		input := fs.String("i", "", "导入文件")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *input == "" {
+			return errors.New("-i is required")
+		}
+		return withMigratedDB(cfg, func(db *gorm.DB) error {
+			f, err := os.Open(*input)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return importData(db, f)
+		})
+	default:
+		return fmt.Errorf("unknown data action %q", action)
+	}
+}
+
+// exportData 将数据以 JSON 写入 w
+func exportData(db *gorm.DB, w io.Writer) error {
+	dump := dataDump{Version: dataDumpVersion, ExportedAt: time.Now()}
+	if err := db.Order("id").Find(&dump.Users).Error; err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.RepairRequests).Error; err != nil {
+		return fmt.Errorf("failed to export repair requests: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.Feedback).Error; err != nil {
+		return fmt.Errorf("failed to export feedback: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.Posts).Error; err != nil {
+		return fmt.Errorf("failed to export posts: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.PostRevisions).Error; err != nil {
+		return fmt.Errorf("failed to export post revisions: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.Categories).Error; err != nil {
+		return fmt.Errorf("failed to export categories: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.Redirects).Error; err != nil {
+		return fmt.Errorf("failed to export category redirects: %w", err)
+	}
+	if err := db.Order("id").Find(&dump.Images).Error; err != nil {
+		return fmt.Errorf("failed to export images: %w", err)
+	}
+	for _, p := range dump.Posts {
+		if p.PreviewToken != "" {
+			if dump.PreviewTokens == nil {
+				dump.PreviewTokens = make(map[uint]string)
+			}
+			dump.PreviewTokens[p.ID] = p.PreviewToken
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+// importData 在一个事务中导入数据，主键冲突时覆盖已有记录
+func importData(db *gorm.DB, r io.Reader) error {
+	var dump dataDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return fmt.Errorf("failed to parse data file: %w", err)
+	}
+	if dump.Version != dataDumpVersion {
+		return fmt.Errorf("unsupported data file version %d", dump.Version)
+	}
+	for i := range dump.Posts {
+		dump.Posts[i].PreviewToken = dump.PreviewTokens[dump.Posts[i].ID]
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		upsert := tx.Clauses(clause.OnConflict{UpdateAll: true}).Session(&gorm.Session{})
+		if len(dump.Users) > 0 {
+			if err := upsert.Create(&dump.Users).Error; err != nil {
+				return fmt.Errorf("failed to import users: %w", err)
+			}
+		}
+		if len(dump.RepairRequests) > 0 {
+			if err := upsert.Create(&dump.RepairRequests).Error; err != nil {
+				return fmt.Errorf("failed to import repair requests: %w", err)
+			}
+		}
+		if len(dump.Feedback) > 0 {
+			if err := upsert.Create(&dump.Feedback).Error; err != nil {
+				return fmt.Errorf("failed to import feedback: %w", err)
+			}
+		}
+		if len(dump.Posts) > 0 {
+			if err := upsert.Create(&dump.Posts).Error; err != nil {
+				return fmt.Errorf("failed to import posts: %w", err)
+			}
+		}
+		if len(dump.PostRevisions) > 0 {
+			if err := upsert.Create(&dump.PostRevisions).Error; err != nil {
+				return fmt.Errorf("failed to import post revisions: %w", err)
+			}
+		}
+		if len(dump.Categories) > 0 {
+			if err := upsert.Create(&dump.Categories).Error; err != nil {
+				return fmt.Errorf("failed to import categories: %w", err)
+			}
+		}
+		if len(dump.Redirects) > 0 {
+			if err := upsert.Create(&dump.Redirects).Error; err != nil {
+				return fmt.Errorf("failed to import category redirects: %w", err)
+			}
+		}
+		if len(dump.Images) > 0 {
+			if err := upsert.Create(&dump.Images).Error; err != nil {
+				return fmt.Errorf("failed to import images: %w", err)
+			}
+		}
+		if err := resetSequences(tx, &models.User{}, &models.RepairRequest{}, &models.Feedback{}, &models.Post{},
+			&models.PostRevision{}, &models.Category{}, &models.CategoryRedirect{}, &models.Image{}); err != nil {
+			return err
+		}
+		fmt.Printf("imported %d users, %d repair requests, %d feedback, %d posts, %d post revisions, %d categories, %d category redirects, %d images\n",
+			len(dump.Users), len(dump.RepairRequests), len(dump.Feedback), len(dump.Posts), len(dump.PostRevisions),
+			len(dump.Categories), len(dump.Redirects), len(dump.Images))
+		return nil
+	})
+}
+
+// resetSequences 将 PostgreSQL 自增序列推进到表中最大的 id 之后，否则显式指定 id 导入后新建记录会主键冲突
+// MySQL 和 SQLite 插入显式 id 时自动推进自增值，不需要处理
+func resetSequences(tx *gorm.DB, tables ...interface{}) error {
+	if tx.Dialector.Name() != config.DriverPostgres {
+		return nil
+	}
+	for _, model := range tables {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse model: %w", err)
+		}
+		table := stmt.Schema.Table
+		sql := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM %s",
+			table, tx.Statement.Quote(table))
+		if err := tx.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to reset sequence of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// runPosts 处理 posts 子命令
+func runPosts(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "import" {
+		return errors.New("usage: posts import -author <username|email>")
+	}
+	fs := flag.NewFlagSet("posts import", flag.ContinueOnError)
+	author := fs.String("author", "", "导入文章的作者（用户名或邮箱）")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *author == "" {
+		return errors.New("-author is required")
+	}
+	return withMigratedDB(cfg, func(db *gorm.DB) error {
+		var user models.User
+		if err := db.Where("username = ? OR email = ?", *author, *author).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("user %q not found", *author)
+			}
+			return err
+		}
+		result, err := service.ImportMarkdownTree(context.Background(), db, storage.NewLocal(cfg.Upload.MarkdownDir, nil), user)
+		fmt.Printf("imported %d posts, skipped %d already imported files\n", result.Imported, result.Skipped)
+		return err
+	})
+}
+
+// randomPassword 生成一个随机密码
+func randomPassword() string {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		panic("无法生成安全随机数")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,661 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 是整个服务的配置，按模块分组
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Redis     RedisConfig     `yaml:"redis" toml:"redis"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	SMTP      SMTPConfig      `yaml:"smtp" toml:"smtp"`
+	Upload    UploadConfig    `yaml:"upload" toml:"upload"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	ImageHost ImageHostConfig `yaml:"image_host" toml:"image_host"`
+	Image     ImageConfig     `yaml:"image" toml:"image"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	Site      SiteConfig      `yaml:"site" toml:"site"`
+	Workers   WorkersConfig   `yaml:"workers" toml:"workers"`
+	Metrics   MetricsConfig   `yaml:"metrics" toml:"metrics"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig HTTP 服务相关配置
+type ServerConfig struct {
+	Port         int      `yaml:"port" toml:"port" env:"REPAIR_SERVER_PORT,PORT"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout" env:"REPAIR_SERVER_READ_TIMEOUT"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"REPAIR_SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"REPAIR_SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout 收到退出信号后等待进行中请求完成的最长时间
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"REPAIR_SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// DatabaseConfig 数据库连接配置
+type DatabaseConfig struct {
+	Driver          string   `yaml:"driver" toml:"driver" env:"REPAIR_DATABASE_DRIVER"` // sqlite, postgres, mysql
+	DSN             string   `yaml:"dsn" toml:"dsn" env:"REPAIR_DATABASE_DSN"`          // sqlite 时为文件路径
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"REPAIR_DATABASE_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"REPAIR_DATABASE_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"REPAIR_DATABASE_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"REPAIR_DATABASE_CONN_MAX_IDLE_TIME"`
+}
+
+// 支持的数据库驱动
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Addr     string `yaml:"addr" toml:"addr" env:"REPAIR_REDIS_ADDR,REDIS_ADDR"`
+	Password string `yaml:"password" toml:"password" env:"REPAIR_REDIS_PASSWORD,REDIS_PASSWORD"`
+	DB       int    `yaml:"db" toml:"db" env:"REPAIR_REDIS_DB"`
+}
+
+// AuthConfig 认证相关配置
+type AuthConfig struct {
+	JWTSecret       string   `yaml:"jwt_secret" toml:"jwt_secret" env:"REPAIR_AUTH_JWT_SECRET"`
+	TokenTTL        Duration `yaml:"token_ttl" toml:"token_ttl" env:"REPAIR_AUTH_TOKEN_TTL"`
+	AdminInviteCode string   `yaml:"admin_invite_code" toml:"admin_invite_code" env:"REPAIR_AUTH_ADMIN_INVITE_CODE"`
+}
+
+// SMTPConfig 发信服务器配置
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"REPAIR_SMTP_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"REPAIR_SMTP_PORT"`
+	Username string `yaml:"username" toml:"username" env:"REPAIR_SMTP_USERNAME"`
+	Password string `yaml:"password" toml:"password" env:"REPAIR_SMTP_PASSWORD"`
+	From     string `yaml:"from" toml:"from" env:"REPAIR_SMTP_FROM"`
+	// QueueSize 异步发信队列容量，MaxRetries 单封邮件失败后的重试次数
+	QueueSize  int `yaml:"queue_size" toml:"queue_size" env:"REPAIR_SMTP_QUEUE_SIZE"`
+	MaxRetries int `yaml:"max_retries" toml:"max_retries" env:"REPAIR_SMTP_MAX_RETRIES"`
+}
+
+// UploadConfig 上传文件存放位置
+type UploadConfig struct {
+	Dir         string `yaml:"dir" toml:"dir" env:"REPAIR_UPLOAD_DIR"`
+	MarkdownDir string `yaml:"markdown_dir" toml:"markdown_dir" env:"REPAIR_UPLOAD_MARKDOWN_DIR,BASE_PATH"`
+}
+
+// 上传文件的存储后端
+const (
+	StorageLocal  = "local"
+	StorageS3     = "s3"
+	StorageMemory = "memory"
+)
+
+// StorageConfig 上传文件的存储后端配置，local 后端以 upload.dir 为根目录；Markdown 目录始终在本地
+type StorageConfig struct {
+	Backend string `yaml:"backend" toml:"backend" env:"REPAIR_STORAGE_BACKEND"` // local, s3, memory
+	// SignedURLTTL 签名下载地址的有效期
+	SignedURLTTL Duration `yaml:"signed_url_ttl" toml:"signed_url_ttl" env:"REPAIR_STORAGE_SIGNED_URL_TTL"`
+	S3           S3Config `yaml:"s3" toml:"s3"`
+}
+
+// S3Config S3 兼容对象存储（AWS S3、MinIO 等）的连接配置
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"REPAIR_STORAGE_S3_ENDPOINT"` // host:port，不带协议
+	Region    string `yaml:"region" toml:"region" env:"REPAIR_STORAGE_S3_REGION"`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"REPAIR_STORAGE_S3_BUCKET"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"REPAIR_STORAGE_S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"REPAIR_STORAGE_S3_SECRET_KEY"`
+	UseSSL    bool   `yaml:"use_ssl" toml:"use_ssl" env:"REPAIR_STORAGE_S3_USE_SSL"`
+}
+
+// 图床类型
+const (
+	ImageHostLocal = "local" // 保存到 storage 配置的存储，由 /media/ 提供
+	ImageHostSMMS  = "smms"  // 上传到 sm.ms
+)
+
+// ImageHostConfig 图床配置
+type ImageHostConfig struct {
+	Provider string `yaml:"provider" toml:"provider" env:"REPAIR_IMAGE_HOST_PROVIDER"`
+	// MediaURL 本地图床图片地址的前缀，前端与后端不同域时配置为后端的绝对地址，如 https://api.example.com/media/
+	MediaURL   string `yaml:"media_url" toml:"media_url" env:"REPAIR_IMAGE_HOST_MEDIA_URL"`
+	SMMSAPIURL string `yaml:"smms_api_url" toml:"smms_api_url" env:"REPAIR_IMAGE_HOST_SMMS_API_URL"`
+	SMMSToken  string `yaml:"smms_token" toml:"smms_token" env:"REPAIR_IMAGE_HOST_SMMS_TOKEN"`
+}
+
+// ImageConfig 上传图片的处理配置，图片会去除 EXIF 等元数据后重新编码
+type ImageConfig struct {
+	MaxDimension int `yaml:"max_dimension" toml:"max_dimension" env:"REPAIR_IMAGE_MAX_DIMENSION"` // 原图最长边超过时等比缩小，0 表示不限
+	MaxPixels    int `yaml:"max_pixels" toml:"max_pixels" env:"REPAIR_IMAGE_MAX_PIXELS"`          // 解码前检查，拒绝像素数过多的图片
+	JPEGQuality  int `yaml:"jpeg_quality" toml:"jpeg_quality" env:"REPAIR_IMAGE_JPEG_QUALITY"`
+	// WebP 为每个派生尺寸额外生成无损 WebP
+	WebP  bool        `yaml:"webp" toml:"webp" env:"REPAIR_IMAGE_WEBP"`
+	Sizes []ImageSize `yaml:"sizes" toml:"sizes"`
+}
+
+// ImageSize 派生尺寸，图片等比缩小到 Width×Height 以内，不会放大；为 0 的边不限制
+type ImageSize struct {
+	Name   string `yaml:"name" toml:"name"` // 用于对象 key，只能包含小写字母、数字和 -
+	Width  int    `yaml:"width" toml:"width"`
+	Height int    `yaml:"height" toml:"height"`
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins" toml:"allow_origins" env:"REPAIR_CORS_ALLOW_ORIGINS"`
+}
+
+// SiteConfig 公开页面、订阅源和站点地图使用的站点信息
+type SiteConfig struct {
+	Title       string `yaml:"title" toml:"title" env:"REPAIR_SITE_TITLE"`
+	Description string `yaml:"description" toml:"description" env:"REPAIR_SITE_DESCRIPTION"`
+	// BaseURL 前端站点的根地址，订阅源和站点地图中的链接以此为前缀
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"REPAIR_SITE_BASE_URL"`
+	// PostPath 前端文章页面的路径前缀，文章地址为 BaseURL + PostPath + slug
+	PostPath  string `yaml:"post_path" toml:"post_path" env:"REPAIR_SITE_POST_PATH"`
+	FeedLimit int    `yaml:"feed_limit" toml:"feed_limit" env:"REPAIR_SITE_FEED_LIMIT"` // 订阅源中的文章数
+}
+
+// WorkersConfig 后台任务配置，*_schedule 为标准 5 段 cron 表达式
+type WorkersConfig struct {
+	SLACheckInterval Duration `yaml:"sla_check_interval" toml:"sla_check_interval" env:"REPAIR_WORKERS_SLA_CHECK_INTERVAL"`
+	// SLAPendingTimeout 报修请求处于待处理状态超过该时长即视为超时
+	SLAPendingTimeout Duration `yaml:"sla_pending_timeout" toml:"sla_pending_timeout" env:"REPAIR_WORKERS_SLA_PENDING_TIMEOUT"`
+	// LockTTL 定时任务锁持有时间的上限，应大于任务耗时；每次执行实际不超过距下次执行间隔的一半
+	LockTTL                 Duration `yaml:"lock_ttl" toml:"lock_ttl" env:"REPAIR_WORKERS_LOCK_TTL"`
+	TokenPurgeSchedule      string   `yaml:"token_purge_schedule" toml:"token_purge_schedule" env:"REPAIR_WORKERS_TOKEN_PURGE_SCHEDULE"`
+	OrphanSweepSchedule     string   `yaml:"orphan_sweep_schedule" toml:"orphan_sweep_schedule" env:"REPAIR_WORKERS_ORPHAN_SWEEP_SCHEDULE"`
+	OrphanGracePeriod       Duration `yaml:"orphan_grace_period" toml:"orphan_grace_period" env:"REPAIR_WORKERS_ORPHAN_GRACE_PERIOD"`
+	SoftDeletePurgeSchedule string   `yaml:"soft_delete_purge_schedule" toml:"soft_delete_purge_schedule" env:"REPAIR_WORKERS_SOFT_DELETE_PURGE_SCHEDULE"`
+	SoftDeleteRetention     Duration `yaml:"soft_delete_retention" toml:"soft_delete_retention" env:"REPAIR_WORKERS_SOFT_DELETE_RETENTION"`
+	// PostPublishSchedule 检查并发布到期定时文章的计划，决定定时发布的精度
+	PostPublishSchedule string `yaml:"post_publish_schedule" toml:"post_publish_schedule" env:"REPAIR_WORKERS_POST_PUBLISH_SCHEDULE"`
+	// SearchReindexInterval 全量重建搜索索引的间隔，用于同步命令行等绕过 API 的修改
+	SearchReindexInterval Duration `yaml:"search_reindex_interval" toml:"search_reindex_interval" env:"REPAIR_WORKERS_SEARCH_REINDEX_INTERVAL"`
+}
+
+// MetricsConfig Prometheus 指标配置
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"REPAIR_METRICS_ENABLED"`
+	// Token 非空时抓取 /metrics 需要携带 Authorization: Bearer <token>
+	Token string `yaml:"token" toml:"token" env:"REPAIR_METRICS_TOKEN"`
+}
+
+// 日志输出目标
+const (
+	LogOutputFile   = "file"
+	LogOutputStdout = "stdout"
+	LogOutputBoth   = "both"
+)
+
+// LogConfig 日志配置，文件输出按大小和保留天数轮转
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"REPAIR_LOG_LEVEL"`    // debug, info, warn, error
+	Output string `yaml:"output" toml:"output" env:"REPAIR_LOG_OUTPUT"` // file, stdout, both
+	File   string `yaml:"file" toml:"file" env:"REPAIR_LOG_FILE"`
+	// MaxSizeMB 单个日志文件的最大大小，超过后轮转
+	MaxSizeMB  int  `yaml:"max_size_mb" toml:"max_size_mb" env:"REPAIR_LOG_MAX_SIZE_MB"`
+	MaxBackups int  `yaml:"max_backups" toml:"max_backups" env:"REPAIR_LOG_MAX_BACKUPS"`
+	MaxAgeDays int  `yaml:"max_age_days" toml:"max_age_days" env:"REPAIR_LOG_MAX_AGE_DAYS"`
+	Compress   bool `yaml:"compress" toml:"compress" env:"REPAIR_LOG_COMPRESS"`
+}
+
+// 链路追踪导出方式
+const (
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+// TracingConfig OpenTelemetry 链路追踪配置
+type TracingConfig struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled" env:"REPAIR_TRACING_ENABLED"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"REPAIR_TRACING_SERVICE_NAME,OTEL_SERVICE_NAME"`
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"REPAIR_TRACING_EXPORTER"` // otlp, stdout, file
+	// Endpoint OTLP/HTTP 接收端地址，如 localhost:4318
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"REPAIR_TRACING_ENDPOINT"`
+	Insecure bool   `yaml:"insecure" toml:"insecure" env:"REPAIR_TRACING_INSECURE"`
+	// File exporter 为 file 时写入的文件，每行一个 JSON 格式的 span
+	File string `yaml:"file" toml:"file" env:"REPAIR_TRACING_FILE"`
+	// SampleRatio 采样比例，0 到 1 之间
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"REPAIR_TRACING_SAMPLE_RATIO"`
+}
+
+// Duration 支持 "10s"、"72h" 这类写法的时长
+type Duration time.Duration
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，供 YAML/TOML 解析使用
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Std 返回标准库的 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default 返回带默认值的配置，密钥类字段不提供默认值
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     Duration(10 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			IdleTimeout:     Duration(30 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverSQLite,
+			DSN:             "repair_platform.db",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(time.Hour),
+			ConnMaxIdleTime: Duration(10 * time.Minute),
+		},
+		Auth: AuthConfig{
+			TokenTTL:        Duration(72 * time.Hour),
+			AdminInviteCode: "JNUTechnicians",
+		},
+		SMTP: SMTPConfig{
+			Port:       587,
+			QueueSize:  100,
+			MaxRetries: 3,
+		},
+		Upload: UploadConfig{
+			Dir:         "./uploads/",
+			MarkdownDir: "./markdown/",
+		},
+		Storage: StorageConfig{
+			Backend:      StorageLocal,
+			SignedURLTTL: Duration(15 * time.Minute),
+			S3: S3Config{
+				Region: "us-east-1",
+				UseSSL: true,
+			},
+		},
+		ImageHost: ImageHostConfig{
+			Provider:   ImageHostLocal,
+			MediaURL:   "/media/",
+			SMMSAPIURL: "https://sm.ms/api/v2/upload",
+		},
+		Image: ImageConfig{
+			MaxDimension: 2560,
+			MaxPixels:    40_000_000,
+			JPEGQuality:  85,
+			WebP:         true,
+			Sizes: []ImageSize{
+				{Name: "thumbnail", Width: 320, Height: 320},
+				{Name: "medium", Width: 1024, Height: 1024},
+			},
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:11451"},
+		},
+		Site: SiteConfig{
+			Title:     "Repair Platform",
+			BaseURL:   "http://localhost:11451",
+			PostPath:  "/posts/",
+			FeedLimit: 20,
+		},
+		Workers: WorkersConfig{
+			SLACheckInterval:        Duration(15 * time.Minute),
+			SLAPendingTimeout:       Duration(48 * time.Hour),
+			LockTTL:                 Duration(10 * time.Minute),
+			TokenPurgeSchedule:      "0 * * * *",
+			OrphanSweepSchedule:     "30 3 * * *",
+			OrphanGracePeriod:       Duration(24 * time.Hour),
+			SoftDeletePurgeSchedule: "0 4 * * *",
+			SoftDeleteRetention:     Duration(30 * 24 * time.Hour),
+			PostPublishSchedule:     "* * * * *",
+			SearchReindexInterval:   Duration(time.Hour),
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Output:     LogOutputFile,
+			File:       "server.log",
+			MaxSizeMB:  100,
+			MaxBackups: 7,
+			MaxAgeDays: 30,
+			Compress:   true,
+		},
+		Tracing: TracingConfig{
+			ServiceName: "repair-platform",
+			Exporter:    TraceExporterOTLP,
+			Endpoint:    "localhost:4318",
+			File:        "traces.json",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load 读取配置文件（.yaml/.yml/.toml），再用环境变量覆盖，最后校验
+// path 为空时只使用默认值和环境变量
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 根据扩展名选择解析器
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate 校验配置，返回所有非法值合并后的错误
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server timeouts must be positive"))
+	}
+	switch c.Database.Driver {
+	case DriverSQLite, DriverPostgres, DriverMySQL:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be one of sqlite, postgres, mysql, got %q", c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database connection pool sizes must not be negative"))
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must not exceed database.max_open_conns"))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database connection lifetimes must not be negative"))
+	}
+	if c.Redis.DB < 0 {
+		errs = append(errs, fmt.Errorf("redis.db must not be negative, got %d", c.Redis.DB))
+	}
+	if len(c.Auth.JWTSecret) < 16 {
+		errs = append(errs, errors.New("auth.jwt_secret is required and must be at least 16 characters"))
+	}
+	if c.Auth.TokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.token_ttl must be positive"))
+	}
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port <= 0 || c.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("smtp.port must be between 1 and 65535, got %d", c.SMTP.Port))
+		}
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("smtp.from is required when smtp.host is set"))
+		}
+	}
+	if u, err := url.Parse(c.Site.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("site.base_url must be an absolute http(s) URL, got %q", c.Site.BaseURL))
+	}
+	if !strings.HasPrefix(c.Site.PostPath, "/") {
+		errs = append(errs, fmt.Errorf("site.post_path must start with /, got %q", c.Site.PostPath))
+	}
+	if c.Site.FeedLimit <= 0 {
+		errs = append(errs, errors.New("site.feed_limit must be positive"))
+	}
+	if c.SMTP.QueueSize <= 0 || c.SMTP.MaxRetries < 0 {
+		errs = append(errs, errors.New("smtp.queue_size must be positive and smtp.max_retries must not be negative"))
+	}
+	if c.Workers.SLACheckInterval <= 0 || c.Workers.SLAPendingTimeout <= 0 || c.Workers.LockTTL <= 0 ||
+		c.Workers.OrphanGracePeriod <= 0 || c.Workers.SoftDeleteRetention <= 0 || c.Workers.SearchReindexInterval <= 0 {
+		errs = append(errs, errors.New("workers intervals and timeouts must be positive"))
+	}
+	for name, spec := range map[string]string{
+		"workers.token_purge_schedule":       c.Workers.TokenPurgeSchedule,
+		"workers.orphan_sweep_schedule":      c.Workers.OrphanSweepSchedule,
+		"workers.soft_delete_purge_schedule": c.Workers.SoftDeletePurgeSchedule,
+		"workers.post_publish_schedule":      c.Workers.PostPublishSchedule,
+	} {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			errs = append(errs, fmt.Errorf("%s is not a valid cron expression: %w", name, err))
+		}
+	}
+	if c.Upload.Dir == "" || c.Upload.MarkdownDir == "" {
+		errs = append(errs, errors.New("upload.dir and upload.markdown_dir are required"))
+	} else if nestedDirs(c.Upload.Dir, c.Upload.MarkdownDir) {
+		// 上传目录的孤立文件清理和 /media 会遍历其中的全部文件，不能与 Markdown 目录重叠
+		errs = append(errs, fmt.Errorf("upload.dir %q and upload.markdown_dir %q must not contain each other", c.Upload.Dir, c.Upload.MarkdownDir))
+	}
+	switch c.Storage.Backend {
+	case StorageLocal, StorageMemory:
+	case StorageS3:
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" || c.Storage.S3.AccessKey == "" || c.Storage.S3.SecretKey == "" {
+			errs = append(errs, errors.New("storage.s3.endpoint, bucket, access_key and secret_key are required when storage.backend is s3"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend must be one of local, s3, memory, got %q", c.Storage.Backend))
+	}
+	if c.Storage.SignedURLTTL <= 0 {
+		errs = append(errs, errors.New("storage.signed_url_ttl must be positive"))
+	}
+	switch c.ImageHost.Provider {
+	case ImageHostLocal:
+		if !strings.HasSuffix(c.ImageHost.MediaURL, "/") {
+			errs = append(errs, fmt.Errorf("image_host.media_url must end with /, got %q", c.ImageHost.MediaURL))
+		}
+	case ImageHostSMMS:
+		if c.ImageHost.SMMSAPIURL == "" || c.ImageHost.SMMSToken == "" {
+			errs = append(errs, errors.New("image_host.smms_api_url and smms_token are required when image_host.provider is smms"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("image_host.provider must be one of local, smms, got %q", c.ImageHost.Provider))
+	}
+	errs = append(errs, c.Image.validate()...)
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allow_origins must contain at least one origin"))
+	}
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level must be one of debug, info, warn, error, got %q", c.Log.Level))
+	}
+	switch c.Log.Output {
+	case LogOutputFile, LogOutputBoth:
+		if c.Log.File == "" {
+			errs = append(errs, errors.New("log.file is required when log.output is file or both"))
+		}
+	case LogOutputStdout:
+	default:
+		errs = append(errs, fmt.Errorf("log.output must be one of file, stdout, both, got %q", c.Log.Output))
+	}
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case TraceExporterOTLP:
+			if c.Tracing.Endpoint == "" {
+				errs = append(errs, errors.New("tracing.endpoint is required when tracing.exporter is otlp"))
+			}
+		case TraceExporterFile:
+			if c.Tracing.File == "" {
+				errs = append(errs, errors.New("tracing.file is required when tracing.exporter is file"))
+			}
+		case TraceExporterStdout:
+		default:
+			errs = append(errs, fmt.Errorf("tracing.exporter must be one of otlp, stdout, file, got %q", c.Tracing.Exporter))
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+		}
+	}
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAgeDays < 0 {
+		errs = append(errs, errors.New("log rotation limits must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// imageSizeName 派生尺寸名称的格式
+var imageSizeName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// validate 校验图片处理配置；WebP 的宽高不能超过 16384
+func (c ImageConfig) validate() []error {
+	var errs []error
+	if c.MaxDimension < 0 || c.MaxDimension > 16384 {
+		errs = append(errs, fmt.Errorf("image.max_dimension must be between 0 and 16384, got %d", c.MaxDimension))
+	}
+	if c.MaxPixels <= 0 {
+		errs = append(errs, errors.New("image.max_pixels must be positive"))
+	}
+	if c.JPEGQuality < 1 || c.JPEGQuality > 100 {
+		errs = append(errs, fmt.Errorf("image.jpeg_quality must be between 1 and 100, got %d", c.JPEGQuality))
+	}
+	seen := make(map[string]bool)
+	for _, size := range c.Sizes {
+		if !imageSizeName.MatchString(size.Name) || size.Name == "original" || seen[size.Name] {
+			errs = append(errs, fmt.Errorf("image.sizes name %q must be unique, not original, and contain only a-z, 0-9 and -", size.Name))
+		}
+		seen[size.Name] = true
+		if size.Width < 0 || size.Height < 0 || size.Width > 16384 || size.Height > 16384 || size.Width+size.Height == 0 {
+			errs = append(errs, fmt.Errorf("image.sizes %q must limit width or height to at most 16384", size.Name))
+		}
+	}
+	return errs
+}
+
+// applyEnv 按字段上的 env 标签读取环境变量，多个变量名用逗号分隔，靠前的优先
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := sf.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		for _, name := range strings.Split(tag, ",") {
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setField(field, raw); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// setField 将字符串形式的环境变量写入对应类型的字段
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(Duration(0)) {
+		var d Duration
+		if err := d.UnmarshalText([]byte(raw)); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// nestedDirs 判断两个目录是否相同或其中一个位于另一个之内
+func nestedDirs(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	inside := func(p, base string) bool {
+		rel, err := filepath.Rel(base, p)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	return inside(absA, absB) || inside(absB, absA)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadYAMLWithEnvOverride(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 9090
+  read_timeout: 5s
+auth:
+  jwt_secret: yaml_secret_value_123
+cors:
+  allow_origins: ["http://example.com"]
+`)
+	t.Setenv("PORT", "7070")
+	t.Setenv("REPAIR_CORS_ALLOW_ORIGINS", "http://a.com, http://b.com")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Server.Port != 7070 {
+		t.Errorf("expected env port 7070, got %d", cfg.Server.Port)
+	}
+	if cfg.Server.ReadTimeout.Std() != 5*time.Second {
+		t.Errorf("expected read timeout 5s, got %s", cfg.Server.ReadTimeout.Std())
+	}
+	if cfg.Auth.JWTSecret != "yaml_secret_value_123" {
+		t.Errorf("unexpected jwt secret %q", cfg.Auth.JWTSecret)
+	}
+	if len(cfg.CORS.AllowOrigins) != 2 || cfg.CORS.AllowOrigins[1] != "http://b.com" {
+		t.Errorf("unexpected allow origins %v", cfg.CORS.AllowOrigins)
+	}
+	if cfg.Database.Driver != DriverSQLite || cfg.Database.DSN != "repair_platform.db" {
+		t.Errorf("expected default sqlite database, got %s %q", cfg.Database.Driver, cfg.Database.DSN)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[auth]
+jwt_secret = "toml_secret_value_123"
+token_ttl = "24h"
+
+[upload]
+markdown_dir = "docs/md"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Auth.TokenTTL.Std() != 24*time.Hour {
+		t.Errorf("expected token ttl 24h, got %s", cfg.Auth.TokenTTL.Std())
+	}
+	if cfg.Upload.MarkdownDir != "docs/md" {
+		t.Errorf("unexpected markdown dir %q", cfg.Upload.MarkdownDir)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	cases := map[string]string{
+		"missing secret": "server:\n  port: 8080\n",
+		"bad port":       "server:\n  port: 70000\nauth:\n  jwt_secret: long_enough_secret_1\n",
+		"smtp no from":   "auth:\n  jwt_secret: long_enough_secret_1\nsmtp:\n  host: smtp.example.com\n",
+		"bad driver":     "auth:\n  jwt_secret: long_enough_secret_1\ndatabase:\n  driver: oracle\n",
+		"nested dirs":    "auth:\n  jwt_secret: long_enough_secret_1\nupload:\n  dir: ./uploads/\n  markdown_dir: uploads/md\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeFile(t, "config.yaml", content)); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+
+	t.Run("bad env value", func(t *testing.T) {
+		t.Setenv("REPAIR_AUTH_JWT_SECRET", "long_enough_secret_1")
+		t.Setenv("REPAIR_SERVER_PORT", "not-a-number")
+		if _, err := Load(""); err == nil {
+			t.Fatal("expected env parse error")
+		}
+	})
+}
@@ -4,15 +4,15 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
-	"fmt"
 	"net/http"
-	"net/smtp"
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/logging"
 	"repair-platform/models"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -34,6 +34,9 @@ type ResetPasswordInput struct {
 	NewPassword string `json:"new_password" binding:"required"`
 }
 
+// verificationCodeTTL 邮箱验证码的有效期
+const verificationCodeTTL = 15 * time.Minute
+
 // APIResponse 标准的API响应结构
 type APIResponse struct {
 	Message string      `json:"message"`
@@ -46,6 +49,7 @@ type RegisterInput struct {
 	Email      string `json:"email" binding:"required,email"`
 	Password   string `json:"password" binding:"required,min=6"`
 	InviteCode string `json:"invite_code"` // 邀请码字段
+	Locale     string `json:"locale"`      // 语言偏好，为空时使用 Accept-Language 协商的结果
 }
 
 // Register 处理用户注册
@@ -56,22 +60,25 @@ type RegisterInput struct {
 // @Produce json
 // @Param user body RegisterInput true "用户注册信息"
 // @Success 200 {object} APIResponse "注册成功，验证码已发送至您的邮箱"
-// @Failure 400 {object} APIResponse "错误请求"
-// @Failure 409 {object} APIResponse "用户名或邮箱已被注册"
-// @Failure 500 {object} APIResponse "创建用户失败"
+// @Failure 400 {object} apperror.Response "错误请求"
+// @Failure 409 {object} apperror.Response "用户名或邮箱已被注册"
+// @Failure 500 {object} apperror.Response "创建用户失败"
 // @Router /register [post]
 func Register(c *gin.Context) {
+	logger := logging.FromContext(c)
+
 	// 记录请求的开始
-	zap.S().Info("开始处理用户注册请求")
+	logger.Info("开始处理用户注册请求")
 
 	// 记录收到的请求体数据（输入绑定）
 	var input RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		zap.S().Error("输入绑定失败: ", err)
-		c.JSON(http.StatusBadRequest, APIResponse{Message: "无效的输入"})
+		logger.Warnw("输入绑定失败", "error", err)
+		apperror.BindError(c, err)
 		return
 	}
-	zap.S().Info("收到的注册数据: ", input)
+	// 请求体中包含密码和邀请码，只记录非敏感字段
+	logger.Infow("收到的注册数据", "username", input.Username, "email", input.Email)
 
 	// 获取数据库连接
 	db := c.MustGet("db").(*gorm.DB)
@@ -79,21 +86,32 @@ func Register(c *gin.Context) {
 	// 检查是否存在相同用户名或邮箱的用户
 	var existingUser models.User
 	if err := db.Where("username = ? OR email = ?", input.Username, input.Email).First(&existingUser).Error; err == nil {
-		zap.S().Info("用户名或邮箱已被注册: ", input.Username, input.Email)
-		c.JSON(http.StatusConflict, APIResponse{Message: "用户名或邮箱已被注册"})
+		logger.Info("用户名或邮箱已被注册: ", input.Username, input.Email)
+		apperror.Abort(c, apperror.ErrAuthUserExists)
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		zap.S().Error("检查用户是否已存在时出错: ", err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "检查用户是否已存在时出错"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.check_user"))
 		return
 	}
 
 	// 设置用户角色，根据邀请码判断角色
-	role := "user"
-	if input.InviteCode == "JNUTechnicians" {
-		role = "admin"
+	role := models.RoleUser
+	inviteCode := getConfig(c).Auth.AdminInviteCode
+	if inviteCode != "" && input.InviteCode == inviteCode {
+		role = models.RoleAdmin
+	}
+	logger.Info("分配的用户角色: ", role)
+
+	// 语言偏好用于之后的 API 提示和邮件
+	locale := i18n.FromContext(c)
+	if input.Locale != "" {
+		l, ok := i18n.Parse(input.Locale)
+		if !ok {
+			apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.invalid_locale"))
+			return
+		}
+		locale = l
 	}
-	zap.S().Info("分配的用户角色: ", role)
 
 	// 设置用户密码并保存用户信息到数据库
 	user := models.User{
@@ -101,20 +119,19 @@ func Register(c *gin.Context) {
 		Email:      input.Email,
 		Role:       role,
 		IsVerified: false,
+		Locale:     string(locale),
 	}
 	if err := user.SetPassword(input.Password); err != nil {
-		zap.S().Error("设置密码失败: ", err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "设置密码失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.set_password"))
 		return
 	}
 
 	// 保存用户
 	if err := db.Create(&user).Error; err != nil {
-		zap.S().Error("创建用户失败: ", err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "创建用户失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.create_user"))
 		return
 	}
-	zap.S().Info("用户已成功创建: ", user)
+	logger.Infow("用户已成功创建", "user_id", user.ID, "username", user.Username)
 
 	// 生成验证码并发送到用户邮箱
 	code := generateSecureCode()
@@ -122,26 +139,24 @@ func Register(c *gin.Context) {
 		UserID:    user.ID,
 		Token:     code,
 		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(15 * time.Minute),
+		ExpiresAt: time.Now().Add(verificationCodeTTL),
 	}
 	if err := db.Create(&token).Error; err != nil {
-		zap.S().Error("保存验证码失败: ", err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "保存验证码失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_code"))
 		return
 	}
-	zap.S().Info("生成的邮箱验证码: ", code)
+	logger.Infow("邮箱验证码已生成", "user_id", user.ID, "expires_at", token.ExpiresAt)
 
 	// 调用sendEmail函数发送验证码
-	if err := sendEmail(user.Email, code); err != nil {
-		zap.S().Error("发送验证码失败: ", err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "发送验证码失败"})
+	if err := sendEmail(c, locale, user.Email, code); err != nil {
+		apperror.Abort(c, apperror.ErrMailSendFailed.Wrap(err))
 		return
 	}
 
 	// 记录请求的结束
-	zap.S().Info("用户注册成功，验证码已发送至用户邮箱: ", user.Email)
+	logger.Info("用户注册成功，验证码已发送至用户邮箱: ", user.Email)
 
-	c.JSON(http.StatusOK, APIResponse{Message: "注册成功，验证码已发送至您的邮箱"})
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.register_success")})
 }
 
 // VerifyEmail 处理用户邮箱验证
@@ -152,16 +167,18 @@ func Register(c *gin.Context) {
 // @Produce json
 // @Param verification body VerifyEmailInput true "邮箱和验证码"
 // @Success 200 {object} APIResponse "邮箱验证成功"
-// @Failure 400 {object} APIResponse "错误请求"
-// @Failure 401 {object} APIResponse "无效或过期的验证码"
-// @Failure 500 {object} APIResponse "服务器内部错误"
+// @Failure 400 {object} apperror.Response "错误请求"
+// @Failure 401 {object} apperror.Response "无效或过期的验证码"
+// @Failure 500 {object} apperror.Response "服务器内部错误"
 // @Router /verify_email [post]
 func VerifyEmail(c *gin.Context) {
+	logger := logging.FromContext(c)
+
 	// 在测试模式下直接通过邮箱验证
 	if gin.Mode() == gin.TestMode {
 		var input VerifyEmailInput
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, APIResponse{Message: "输入无效"})
+			apperror.BindError(c, err)
 			return
 		}
 		email := input.Email
@@ -169,7 +186,7 @@ func VerifyEmail(c *gin.Context) {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, APIResponse{Message: "用户未找到"})
+			apperror.Abort(c, apperror.ErrUserNotFound.Wrap(err))
 			return
 		}
 
@@ -177,18 +194,18 @@ func VerifyEmail(c *gin.Context) {
 		user.IsVerified = true
 		db.Save(&user)
 
-		c.JSON(http.StatusOK, APIResponse{Message: "邮箱验证成功"})
+		c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.email_verified")})
 		return
 	}
 
 	// 正常模式下执行邮箱验证逻辑
 	var input VerifyEmailInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		zap.S().Error("邮箱验证失败: 输入无效 - ", err)
-		c.JSON(http.StatusBadRequest, APIResponse{Message: "输入无效"})
+		logger.Warnw("邮箱验证失败: 输入无效", "error", err)
+		apperror.BindError(c, err)
 		return
 	}
-	zap.S().Infof("收到邮箱验证请求, Email: %s, Code: %s", input.Email, input.Code)
+	logger.Infof("收到邮箱验证请求, Email: %s", input.Email)
 
 	// 获取数据库连接
 	db := c.MustGet("db").(*gorm.DB)
@@ -198,11 +215,10 @@ func VerifyEmail(c *gin.Context) {
 	err := db.Where("token = ? AND expires_at > ?", input.Code, time.Now()).First(&token).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			zap.S().Warnf("邮箱验证失败: 无效或过期的验证码, Code: %s", input.Code)
-			c.JSON(http.StatusUnauthorized, APIResponse{Message: "无效或过期的验证码"})
+			logger.Warnf("邮箱验证失败: 无效或过期的验证码, Email: %s", input.Email)
+			apperror.Abort(c, apperror.ErrAuthInvalidCode)
 		} else {
-			zap.S().Error("邮箱验证失败: 查询验证码时出错 - ", err)
-			c.JSON(http.StatusInternalServerError, APIResponse{Message: "服务器内部错误"})
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err))
 		}
 		return
 	}
@@ -211,27 +227,27 @@ func VerifyEmail(c *gin.Context) {
 	var user models.User
 	err = db.Where("id = ?", token.UserID).First(&user).Error
 	if err != nil {
-		zap.S().Errorf("邮箱验证失败: 无法找到用户, UserID: %d - Error: %s", token.UserID, err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "服务器内部错误"})
+		logger.Errorf("邮箱验证失败: 无法找到用户, UserID: %d", token.UserID)
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err))
 		return
 	}
 
 	// 更新用户状态为已验证
 	user.IsVerified = true
 	if err := db.Save(&user).Error; err != nil {
-		zap.S().Errorf("邮箱验证失败: 无法更新用户状态, UserID: %d - Error: %s", user.ID, err)
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "服务器内部错误"})
+		logger.Errorf("邮箱验证失败: 无法更新用户状态, UserID: %d", user.ID)
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err))
 		return
 	}
-	zap.S().Infof("用户状态已更新为已验证, UserID: %d", user.ID)
+	logger.Infof("用户状态已更新为已验证, UserID: %d", user.ID)
 
 	// 删除验证码记录
 	if err := db.Delete(&token).Error; err != nil {
-		zap.S().Warnf("邮箱验证完成，但无法删除验证码记录, TokenID: %d - Error: %s", token.ID, err)
+		logger.Warnf("邮箱验证完成，但无法删除验证码记录, TokenID: %d - Error: %s", token.ID, err)
 	}
 
-	zap.S().Infof("邮箱验证成功, UserID: %d, Email: %s", user.ID, user.Email)
-	c.JSON(http.StatusOK, APIResponse{Message: "邮箱验证成功"})
+	logger.Infof("邮箱验证成功, UserID: %d, Email: %s", user.ID, user.Email)
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.email_verified")})
 }
 
 // Login 处理用户登录
@@ -242,14 +258,14 @@ func VerifyEmail(c *gin.Context) {
 // @Produce json
 // @Param login body models.LoginInput true "用户名/邮箱和密码"
 // @Success 200 {object} APIResponse "登录成功，返回 JWT 令牌"
-// @Failure 400 {object} APIResponse "错误请求"
-// @Failure 401 {object} APIResponse "用户名或密码无效或邮箱未验证"
-// @Failure 500 {object} APIResponse "查询用户失败"
+// @Failure 400 {object} apperror.Response "错误请求"
+// @Failure 401 {object} apperror.Response "用户名或密码无效或邮箱未验证"
+// @Failure 500 {object} apperror.Response "查询用户失败"
 // @Router /login [post]
 func Login(c *gin.Context) {
 	var input models.LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{Message: err.Error()})
+		apperror.BindError(c, err)
 		return
 	}
 
@@ -261,9 +277,9 @@ func Login(c *gin.Context) {
 		// 如果是邮箱，按邮箱查询
 		if err := db.Where("email = ?", input.Username).First(&user).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusUnauthorized, APIResponse{Message: "邮箱或密码无效"})
+				apperror.Abort(c, apperror.ErrAuthInvalidCredentials.WithMessage("error.auth.invalid_email_credentials"))
 			} else {
-				c.JSON(http.StatusInternalServerError, APIResponse{Message: "查询用户失败"})
+				apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.query_user"))
 			}
 			return
 		}
@@ -271,9 +287,9 @@ func Login(c *gin.Context) {
 		// 否则按用户名查询
 		if err := db.Where("username = ?", input.Username).First(&user).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusUnauthorized, APIResponse{Message: "用户名或密码无效"})
+				apperror.Abort(c, apperror.ErrAuthInvalidCredentials)
 			} else {
-				c.JSON(http.StatusInternalServerError, APIResponse{Message: "查询用户失败"})
+				apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.query_user"))
 			}
 			return
 		}
@@ -281,25 +297,26 @@ func Login(c *gin.Context) {
 
 	// 检查用户是否已验证邮箱
 	if !user.IsVerified {
-		c.JSON(http.StatusUnauthorized, APIResponse{Message: "邮箱未验证"})
+		apperror.Abort(c, apperror.ErrAuthEmailNotVerified)
 		return
 	}
 
 	// 验证密码是否正确
 	if !user.CheckPassword(input.Password) {
-		c.JSON(http.StatusUnauthorized, APIResponse{Message: "用户名或密码无效"})
+		apperror.Abort(c, apperror.ErrAuthInvalidCredentials)
 		return
 	}
 
 	// 生成JWT令牌
-	token, err := models.GenerateJWT(user.Username, user.Role)
+	authCfg := getConfig(c).Auth
+	token, err := models.GenerateJWT(user.Username, user.Role, user.Locale, authCfg.JWTSecret, authCfg.TokenTTL.Std())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "生成令牌失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.generate_token"))
 		return
 	}
 
 	// 返回JWT令牌
-	c.JSON(http.StatusOK, APIResponse{Message: "登录成功", Data: map[string]interface{}{"token": token}})
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.login_success"), Data: map[string]interface{}{"token": token}})
 }
 
 // SendVerificationCode 发送邮箱验证码
@@ -310,21 +327,21 @@ func Login(c *gin.Context) {
 // @Produce json
 // @Param email body SendVerificationCodeInput true "用户邮箱"
 // @Success 200 {object} APIResponse "验证码已发送至您的邮箱"
-// @Failure 400 {object} APIResponse "错误请求"
-// @Failure 404 {object} APIResponse "用户未找到"
-// @Failure 500 {object} APIResponse "发送验证码失败"
+// @Failure 400 {object} apperror.Response "错误请求"
+// @Failure 404 {object} apperror.Response "用户未找到"
+// @Failure 500 {object} apperror.Response "发送验证码失败"
 // @Router /send_verification_code [post]
 func SendVerificationCode(c *gin.Context) {
 	var input SendVerificationCodeInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{Message: err.Error()})
+		apperror.BindError(c, err)
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 	var user models.User
 	if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{Message: "用户未找到"})
+		apperror.Abort(c, apperror.ErrUserNotFound)
 		return
 	}
 
@@ -336,16 +353,16 @@ func SendVerificationCode(c *gin.Context) {
 		UserID:    user.ID,
 		Token:     code,
 		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(15 * time.Minute),
+		ExpiresAt: time.Now().Add(verificationCodeTTL),
 	}
 	db.Create(&token)
 
-	if err := sendEmail(input.Email, code); err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "发送验证码失败"})
+	if err := sendEmail(c, userLocale(c, &user), input.Email, code); err != nil {
+		apperror.Abort(c, apperror.ErrMailSendFailed.Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, APIResponse{Message: "验证码已发送至您的邮箱"})
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.code_sent")})
 }
 
 // ResetPassword 重置密码
@@ -356,38 +373,38 @@ func SendVerificationCode(c *gin.Context) {
 // @Produce json
 // @Param reset body ResetPasswordInput true "邮箱、验证码和新密码"
 // @Success 200 {object} APIResponse "密码已成功重置"
-// @Failure 400 {object} APIResponse "错误请求"
-// @Failure 401 {object} APIResponse "无效或过期的验证码"
-// @Failure 500 {object} APIResponse "用户未找到或无法更新密码"
+// @Failure 400 {object} apperror.Response "错误请求"
+// @Failure 401 {object} apperror.Response "无效或过期的验证码"
+// @Failure 500 {object} apperror.Response "用户未找到或无法更新密码"
 // @Router /reset_password [post]
 func ResetPassword(c *gin.Context) {
 	var input ResetPasswordInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{Message: err.Error()})
+		apperror.BindError(c, err)
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 	var resetToken models.PasswordResetToken
 	if err := db.Where("token = ? AND expires_at > ?", input.Token, time.Now()).First(&resetToken).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, APIResponse{Message: "无效或过期的验证码"})
+		apperror.Abort(c, apperror.ErrAuthInvalidCode)
 		return
 	}
 
 	var user models.User
 	if err := db.Where("id = ?", resetToken.UserID).First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "用户未找到"})
+		apperror.Abort(c, apperror.ErrUserNotFound.Wrap(err))
 		return
 	}
 
 	if err := user.SetPassword(input.NewPassword); err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{Message: "无法更新密码"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.update_password"))
 		return
 	}
 	db.Save(&user)
 	db.Delete(&resetToken) // 密码重置成功后删除验证码记录
 
-	c.JSON(http.StatusOK, APIResponse{Message: "密码已成功重置"})
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.password_reset")})
 }
 
 // Helper functions
@@ -402,26 +419,21 @@ func generateSecureCode() string {
 	return hex.EncodeToString(b)
 }
 
-// sendEmail 发送邮件，传递收件人邮箱和验证码
-func sendEmail(to, code string) error {
-	from := "xloudmaxx@gmail.com"
-	password := "mbbf hrde wlpk bphe"
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-
-	// 构建邮件内容
-	msg := "From: " + from + "\n" +
-		"To: " + to + "\n" +
-		"Subject: 邮箱验证码\n\n" +
-		"您的验证码是: " + code
-
-	// 发送邮件
-	err := smtp.SendMail(smtpHost+":"+smtpPort,
-		smtp.PlainAuth("", from, password, smtpHost),
-		from, []string{to}, []byte(msg))
-	if err != nil {
-		fmt.Printf("发送邮件失败: %v\n", err)
+// sendEmail 通过邮件服务按收件人的语言发送验证码
+func sendEmail(c *gin.Context, locale i18n.Locale, to, code string) error {
+	subject := i18n.T(locale, "mail.verification_code.subject")
+	body := i18n.T(locale, "mail.verification_code.body", code, int(verificationCodeTTL/time.Minute))
+	if err := getEmailService(c).SendMail(to, subject, body); err != nil {
+		logging.FromContext(c).Errorf("发送邮件失败: %v", err)
 		return err
 	}
 	return nil
 }
+
+// userLocale 返回用户的语言偏好，未设置时使用当前请求的语言
+func userLocale(c *gin.Context, user *models.User) i18n.Locale {
+	if l, ok := i18n.Parse(user.Locale); ok {
+		return l
+	}
+	return i18n.FromContext(c)
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/models"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CategoryInput 创建或修改分类的请求体
+type CategoryInput struct {
+	Path        string `json:"path" binding:"required"` // 多级路径，以 / 分隔
+	Name        string `json:"name" binding:"max=100"`  // 显示名，为空时使用路径的最后一段
+	Description string `json:"description" binding:"max=500"`
+	Sort        int    `json:"sort"`
+}
+
+// MoveCategoryInput 移动或改名分类的请求体
+type MoveCategoryInput struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// CategoryPage 分类页面：分类信息、子分类和分类下（包含子分类）已发布的文章
+type CategoryPage struct {
+	Category *service.CategoryNode `json:"category"`
+	PostList
+}
+
+// ListCategories 返回分类树，只统计已发布的文章
+// @Summary 分类树
+// @Tags 公开
+// @Produce json
+// @Success 200 {array} service.CategoryNode "分类树"
+// @Router /public/categories [get]
+func ListCategories(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	tree, err := service.CategoryTree(db, nil, true)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_categories"))
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// GetCategory 分类页面，分类已移动时重定向到新路径
+// @Summary 分类页面
+// @Tags 公开
+// @Produce json
+// @Param path path string true "分类路径，多级以 / 分隔"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} CategoryPage "分类和文章列表"
+// @Success 301 "分类已移动"
+// @Failure 404 {object} apperror.Response "分类不存在"
+// @Router /public/categories/{path} [get]
+func GetCategory(c *gin.Context) {
+	folder := strings.Trim(c.Param("path"), "/")
+	if !isValidFolderPath(folder) {
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	tree, err := service.CategoryTree(db, nil, true)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_categories"))
+		return
+	}
+	node := service.Find(tree, folder)
+	if node == nil {
+		if redirectCategory(c, folder, func(to string) string { return "/api/public/categories/" + escapeFolderPath(to) }) {
+			return
+		}
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	}
+
+	query := inFolder(db.Model(&models.Post{}).Where("status = ?", models.PostPublished), folder)
+	posts, err := paginatePosts(c, query)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CategoryPage{Category: node, PostList: posts})
+}
+
+// SaveCategory 按路径创建或修改分类的显示名、描述和排序，同时创建对应的文件夹
+// @Summary 保存分类
+// @Tags 分类
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body CategoryInput true "分类信息"
+// @Success 200 {object} APIResponse{data=models.Category} "分类已保存"
+// @Failure 400 {object} apperror.Response "路径无效"
+// @Router /admin/categories [put]
+func SaveCategory(c *gin.Context) {
+	var input CategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	if !isValidFolderPath(input.Path) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
+		return
+	}
+
+	files := markdownStorage(c)
+	if !files.Contains(input.Path) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+	if err := files.MkdirAll(input.Path); err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.create_folder"))
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	category := models.Category{Path: input.Path, Name: input.Name, Description: input.Description, Sort: input.Sort}
+	if err := service.SaveCategory(db, &category); err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_category"))
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.category_saved"), Data: category})
+}
+
+// MoveCategory 移动或改名分类，子分类、文章和文件夹一起移动，文章 slug 不变，旧路径重定向到新路径
+// @Summary 移动分类
+// @Tags 分类
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body MoveCategoryInput true "原路径和新路径"
+// @Success 200 {object} APIResponse{data=service.MoveResult} "分类已移动"
+// @Failure 400 {object} apperror.Response "路径无效"
+// @Failure 404 {object} apperror.Response "分类不存在"
+// @Failure 409 {object} apperror.Response "新路径已存在"
+// @Router /admin/categories/move [post]
+func MoveCategory(c *gin.Context) {
+	var input MoveCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	if !isValidFolderPath(input.From) || !isValidFolderPath(input.To) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
+		return
+	}
+	files := markdownStorage(c)
+	if !files.Contains(input.From) || !files.Contains(input.To) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	result, err := service.MoveCategory(db, files, input.From, input.To)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	case errors.Is(err, service.ErrCategoryExists):
+		apperror.Abort(c, apperror.ErrFolderExists)
+		return
+	case errors.Is(err, service.ErrCategoryIntoSelf):
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.move_into_self"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.move_category"))
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.category_moved", result.From, result.To, result.Posts), Data: result})
+}
+
+// redirectCategory 分类 folder 已移动时以 301 重定向到 location(新路径)，原请求的查询参数保留
+// 已写入响应（重定向或错误）时返回 true
+func redirectCategory(c *gin.Context, folder string, location func(to string) string) bool {
+	db := c.MustGet("db").(*gorm.DB)
+	to, ok, err := service.ResolveCategoryRedirect(db, folder)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		return true
+	}
+	if !ok {
+		return false
+	}
+	target := location(to)
+	if c.Request.URL.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+	c.Abort()
+	return true
+}
+
+// escapeFolderPath 逐级转义多级文件夹路径，用于拼接 URL
+func escapeFolderPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+
+	"repair-platform/apperror"
+	"repair-platform/config"
+	"repair-platform/imagehost"
+	"repair-platform/models"
+	"repair-platform/service"
+	"repair-platform/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// getConfig 从上下文中取出路由注入的配置
+func getConfig(c *gin.Context) *config.Config {
+	return c.MustGet("config").(*config.Config)
+}
+
+// getEmailService 从上下文中取出路由注入的邮件服务
+func getEmailService(c *gin.Context) service.EmailService {
+	return c.MustGet("emailService").(service.EmailService)
+}
+
+// getStorage 从上下文中取出路由注入的上传文件存储
+func getStorage(c *gin.Context) storage.Storage {
+	return c.MustGet("storage").(storage.Storage)
+}
+
+// getImageHost 从上下文中取出路由注入的图床
+func getImageHost(c *gin.Context) imagehost.Provider {
+	return c.MustGet("imageHost").(imagehost.Provider)
+}
+
+// markdownStorage 返回 Markdown 目录的存储，文件夹是分类的来源，因此始终使用本地目录
+func markdownStorage(c *gin.Context) *storage.Local {
+	return storage.NewLocal(getBasePath(c), nil)
+}
+
+// currentUser 按 JWT 中的用户名查询当前用户，返回的错误均为 *apperror.Error
+func currentUser(c *gin.Context) (*models.User, error) {
+	db := c.MustGet("db").(*gorm.DB)
+	var user models.User
+	if err := db.Where("username = ?", c.GetString("username")).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.query_user")
+	}
+	return &user, nil
+}
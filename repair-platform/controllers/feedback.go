@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
+	"repair-platform/apperror"
+	"repair-platform/i18n"
 	"repair-platform/models"
 )
 
@@ -15,16 +18,16 @@ import (
 // @Produce json
 // @Param feedback body models.Feedback true "反馈内容"
 // @Success 200 {object} map[string]interface{} "反馈提交成功"
-// @Failure 400 {object} map[string]interface{} "输入数据无效"
-// @Failure 404 {object} map[string]interface{} "未找到维修请求"
-// @Failure 500 {object} map[string]interface{} "提交反馈失败"
+// @Failure 400 {object} apperror.Response "输入数据无效"
+// @Failure 404 {object} apperror.Response "未找到维修请求"
+// @Failure 500 {object} apperror.Response "提交反馈失败"
 // @Router /feedback [post]
 func SubmitFeedback(c *gin.Context) {
 	var feedback models.Feedback
 
 	// 绑定 JSON 数据到反馈模型
 	if err := c.ShouldBindJSON(&feedback); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "输入数据无效: " + err.Error()})
+		apperror.BindError(c, err)
 		return
 	}
 
@@ -33,10 +36,10 @@ func SubmitFeedback(c *gin.Context) {
 	// 验证相关的维修请求是否存在
 	var repairRequest models.RepairRequest
 	if err := db.Where("id = ?", feedback.RepairID).First(&repairRequest).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "未找到维修请求"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Abort(c, apperror.ErrRepairNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "检查维修请求失败: " + err.Error()})
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.check_repair"))
 		}
 		return
 	}
@@ -46,12 +49,13 @@ func SubmitFeedback(c *gin.Context) {
 
 	// 将反馈数据保存到数据库
 	if err := db.Create(&feedback).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交反馈失败: " + err.Error()})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.submit_feedback"))
 		return
 	}
+	syncRepairIndex(c, feedback.RepairID)
 
 	// 反馈提交成功
-	c.JSON(http.StatusOK, gin.H{"message": "反馈提交成功", "feedback": feedback})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "msg.feedback_submitted"), "feedback": feedback})
 }
 
 // GetFeedbackByRepairID 允许管理员或用户查看特定维修请求的反馈
@@ -61,8 +65,8 @@ func SubmitFeedback(c *gin.Context) {
 // @Produce json
 // @Param id path string true "维修请求ID"
 // @Success 200 {array} models.Feedback "反馈记录"
-// @Failure 404 {object} map[string]interface{} "未找到此维修请求的反馈"
-// @Failure 500 {object} map[string]interface{} "检索反馈失败"
+// @Failure 404 {object} apperror.Response "未找到此维修请求的反馈"
+// @Failure 500 {object} apperror.Response "检索反馈失败"
 // @Router /feedback/{id} [get]
 func GetFeedbackByRepairID(c *gin.Context) {
 	repairID := c.Param("id")
@@ -72,13 +76,13 @@ func GetFeedbackByRepairID(c *gin.Context) {
 
 	// 查找与特定维修请求关联的所有反馈记录
 	if err := db.Where("repair_id = ?", repairID).Find(&feedbacks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "检索反馈失败: " + err.Error()})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_feedback"))
 		return
 	}
 
 	// 如果没有找到反馈
 	if len(feedbacks) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "未找到此维修请求的反馈"})
+		apperror.Abort(c, apperror.ErrFeedbackNotFound)
 		return
 	}
 
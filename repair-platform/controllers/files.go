@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/models"
+	"repair-platform/search"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RenameFolderInput 文件夹改名的请求体，只修改最后一级名称；移动到其他位置使用 /admin/categories/move
+type RenameFolderInput struct {
+	Folder string `json:"folder" binding:"required"`
+	Name   string `json:"name" binding:"required"`
+}
+
+// MoveFileInput 移动或改名 Markdown 文件的请求体，为空的字段保持不变
+type MoveFileInput struct {
+	Folder string `json:"folder"`
+	File   string `json:"file"`
+}
+
+// RenameFolder 修改文件夹名称，子文件夹、文件和文章随之移动，旧路径保留重定向
+// @Summary 文件夹改名
+// @Tags 文件夹
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body RenameFolderInput true "文件夹和新名称"
+// @Success 200 {object} APIResponse{data=service.MoveResult} "文件夹已改名"
+// @Failure 400 {object} apperror.Response "名称无效"
+// @Failure 404 {object} apperror.Response "文件夹不存在"
+// @Failure 409 {object} apperror.Response "新名称已被使用"
+// @Router /upload/folders [patch]
+func RenameFolder(c *gin.Context) {
+	requireAdmin(c)
+	if c.IsAborted() {
+		return
+	}
+
+	var input RenameFolderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	to := input.Name
+	if parent := models.ParentPath(input.Folder); parent != "" {
+		to = parent + "/" + input.Name
+	}
+	if !isValidFolderPath(input.Folder) || !isValidFolderName(input.Name) || !isValidFolderPath(to) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
+		return
+	}
+	files := markdownStorage(c)
+	if !files.Contains(input.Folder) || !files.Contains(to) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	result, err := service.MoveCategory(db, files, input.Folder, to)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	case errors.Is(err, service.ErrCategoryExists):
+		apperror.Abort(c, apperror.ErrFolderExists)
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.move_category"))
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.folder_renamed", to), Data: result})
+}
+
+// DeleteFolder 删除文件夹；非空文件夹需要 recursive=true 且 confirm 与文件夹路径一致，其中的文章一并删除
+// @Summary 删除文件夹
+// @Tags 文件夹
+// @Produce json
+// @Security BearerAuth
+// @Param folder query string true "文件夹路径"
+// @Param recursive query bool false "删除非空文件夹及其全部内容"
+// @Param confirm query string false "recursive 为 true 时必须与 folder 相同"
+// @Success 200 {object} APIResponse{data=service.DeleteFolderResult} "文件夹已删除"
+// @Failure 400 {object} apperror.Response "路径无效或未确认"
+// @Failure 404 {object} apperror.Response "文件夹不存在"
+// @Failure 409 {object} apperror.Response "文件夹不为空"
+// @Router /upload/folders [delete]
+func DeleteFolder(c *gin.Context) {
+	requireAdmin(c)
+	if c.IsAborted() {
+		return
+	}
+
+	folder := c.Query("folder")
+	if !isValidFolderPath(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
+		return
+	}
+	files := markdownStorage(c)
+	if !files.Contains(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+	recursive := c.Query("recursive") == "true"
+	if recursive && c.Query("confirm") != folder {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.confirm_delete"))
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	result, err := service.DeleteFolder(db, files, folder, recursive)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	case errors.Is(err, service.ErrFolderNotEmpty):
+		apperror.Abort(c, apperror.ErrFolderNotEmpty)
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.delete_folder"))
+		return
+	}
+	for _, id := range result.Posts {
+		search.Default.Remove(search.KindPost, id)
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.folder_deleted", len(result.Posts)), Data: result})
+}
+
+// MoveMarkdownFile 修改 Markdown 文件的名称或所在文件夹，对应文章的 slug 不变
+// @Summary 移动或改名 Markdown 文件
+// @Tags 文件夹
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param file path string true "文件名"
+// @Param folder query string true "文件夹"
+// @Param input body MoveFileInput true "新的文件夹和文件名"
+// @Success 200 {object} APIResponse "文件已移动"
+// @Failure 400 {object} apperror.Response "路径无效"
+// @Failure 404 {object} apperror.Response "文件不存在"
+// @Failure 409 {object} apperror.Response "目标文件已存在"
+// @Router /markdown/{file} [patch]
+func MoveMarkdownFile(c *gin.Context) {
+	requireAdmin(c)
+	if c.IsAborted() {
+		return
+	}
+
+	var input MoveFileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	from, err := markdownFilePath(c, c.Query("folder"), c.Param("file"))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	folder, file := path.Split(from)
+	if input.Folder != "" {
+		folder = input.Folder
+	}
+	if input.File != "" {
+		file = input.File
+	}
+	to, err := markdownFilePath(c, path.Clean(folder), file)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, err := service.MoveMarkdownFile(db, markdownStorage(c), from, to)
+	if err != nil {
+		apperror.Abort(c, fileOperationError(err, "error.internal.move_file"))
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.file_moved", to), Data: gin.H{"path": to, "post": post}})
+}
+
+// DeleteMarkdownFile 删除 Markdown 文件，对应的文章一并删除
+// @Summary 删除 Markdown 文件
+// @Tags 文件夹
+// @Produce json
+// @Security BearerAuth
+// @Param file path string true "文件名"
+// @Param folder query string true "文件夹"
+// @Success 200 {object} APIResponse "文件已删除"
+// @Failure 400 {object} apperror.Response "路径无效"
+// @Failure 404 {object} apperror.Response "文件不存在"
+// @Router /markdown/{file} [delete]
+func DeleteMarkdownFile(c *gin.Context) {
+	requireAdmin(c)
+	if c.IsAborted() {
+		return
+	}
+
+	p, err := markdownFilePath(c, c.Query("folder"), c.Param("file"))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	post, err := service.DeleteMarkdownFile(db, markdownStorage(c), p)
+	if err != nil {
+		apperror.Abort(c, fileOperationError(err, "error.internal.delete_file"))
+		return
+	}
+	if post != nil {
+		search.Default.Remove(search.KindPost, post.ID)
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.file_deleted")})
+}
+
+// markdownFilePath 校验文件夹和文件名，返回以 / 分隔的相对路径，返回的错误均为 *apperror.Error
+func markdownFilePath(c *gin.Context, folder, file string) (string, error) {
+	if !isValidFolderPath(folder) {
+		return "", apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name")
+	}
+	if !isValidMarkdownFileName(file) {
+		return "", apperror.ErrInvalidPath.WithMessage("error.path.invalid_file_name")
+	}
+	p := folder + "/" + file
+	if !markdownStorage(c).Contains(p) {
+		return "", apperror.ErrInvalidPath
+	}
+	return p, nil
+}
+
+// fileOperationError 将 service 中文件操作的错误转换为 API 错误，其他错误为使用 key 作为提示信息的内部错误
+func fileOperationError(err error, key string) *apperror.Error {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		return apperror.ErrFileNotFound
+	case errors.Is(err, service.ErrFileExists):
+		return apperror.ErrFileExists
+	default:
+		return apperror.ErrInternal.Wrap(err).WithMessage(key)
+	}
+}
@@ -1,35 +1,34 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"io/fs"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"path"
 	"path/filepath"
+	"repair-platform/apperror"
+	"repair-platform/frontmatter"
+	"repair-platform/i18n"
+	"repair-platform/logging"
+	"repair-platform/markdown"
+	"repair-platform/metrics"
+	"repair-platform/models"
+	"repair-platform/service"
+	"repair-platform/storage"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
-const defaultBasePath = "uploads/test"
-
-// getBasePath 动态获取基础路径
-func getBasePath() string {
-	if basePath := os.Getenv("BASE_PATH"); basePath != "" {
-		return basePath
-	}
-	return defaultBasePath
-}
-
-// isPathInsideBase 验证路径是否在基础路径内
-func isPathInsideBase(path, base string) bool {
-	absBase, _ := filepath.Abs(base)
-	absPath, _ := filepath.Abs(path)
-	return strings.HasPrefix(absPath, absBase)
-}
-
-// ensureFolderExists 确保文件夹路径存在
-func ensureFolderExists(folderPath string) error {
-	return os.MkdirAll(folderPath, os.ModePerm)
+// getBasePath 从配置中获取 Markdown 文件的基础路径
+func getBasePath(c *gin.Context) string {
+	return getConfig(c).Upload.MarkdownDir
 }
 
 // isValidFolderName 验证文件夹名称是否有效
@@ -43,38 +42,71 @@ func isValidFolderName(name string) bool {
 	return true
 }
 
+// maxFolderPathLength 分类路径的最大字符数，与文章 Folder 字段的长度一致
+const maxFolderPathLength = 100
+
+// isValidFolderPath 验证多级文件夹路径，各级以 / 分隔，每一级都必须是有效的文件夹名称
+func isValidFolderPath(p string) bool {
+	if p == "" || utf8.RuneCountInString(p) > maxFolderPathLength {
+		return false
+	}
+	for _, name := range strings.Split(p, "/") {
+		if name == "" || !isValidFolderName(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidMarkdownFileName 验证 Markdown 文件名：不含路径分隔符，不以 . 开头，扩展名为 .md
+func isValidMarkdownFileName(name string) bool {
+	return filepath.Ext(name) == ".md" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, "/\\\x00")
+}
+
 // requireAdmin 检查管理员权限
 func requireAdmin(c *gin.Context) {
 	role, _ := c.Get("role")
 	if role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
-		c.Abort()
+		apperror.Abort(c, apperror.ErrForbidden)
 	}
 }
 
-// GetFolders 返回现有文件夹列表
+// GetFolders 返回现有文件夹列表，folders 为全部多级文件夹路径，tree 为合并了分类信息的分类树
 func GetFolders(c *gin.Context) {
 	requireAdmin(c)
 	if c.IsAborted() {
 		return
 	}
 
-	basePath := getBasePath()
-	var folders []string
-	files, err := os.ReadDir(basePath)
+	folders, err := listFolderPaths(c.Request.Context(), markdownStorage(c))
 	if err != nil {
-		zap.S().Error("无法读取文件夹列表: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法读取文件夹列表"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_folders"))
 		return
 	}
-
-	for _, file := range files {
-		if file.IsDir() {
-			folders = append(folders, file.Name())
-		}
+	db := c.MustGet("db").(*gorm.DB)
+	tree, err := service.CategoryTree(db, folders, false)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_folders"))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"folders": folders})
+	c.JSON(http.StatusOK, gin.H{"folders": folders, "tree": tree})
+}
+
+// listFolderPaths 返回 files 中所有名称有效的多级文件夹路径，以 / 分隔
+func listFolderPaths(ctx context.Context, files *storage.Local) ([]string, error) {
+	folders := []string{}
+	err := files.Walk(ctx, func(key string, entry fs.DirEntry) error {
+		if !entry.IsDir() {
+			return nil
+		}
+		if !isValidFolderPath(key) {
+			return fs.SkipDir
+		}
+		folders = append(folders, key)
+		return nil
+	})
+	return folders, err
 }
 
 // CreateFolder 创建文件夹
@@ -88,152 +120,335 @@ func CreateFolder(c *gin.Context) {
 		Folder string `json:"folder" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文件夹名称"})
+		apperror.BindError(c, err)
 		return
 	}
 
-	if !isValidFolderName(request.Folder) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件夹名称包含非法字符"})
+	if !isValidFolderPath(request.Folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
 		return
 	}
 
-	basePath := getBasePath()
-	folderPath := filepath.Join(basePath, request.Folder)
-	if err := ensureFolderExists(folderPath); err != nil {
-		zap.S().Error("创建文件夹失败: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文件夹失败"})
+	files := markdownStorage(c)
+	if !files.Contains(request.Folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+	if err := files.MkdirAll(request.Folder); err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.create_folder"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "文件夹创建成功"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "msg.folder_created")})
 }
 
-// UploadFile 处理文件上传
+// UploadFile 处理 Markdown 文件上传，解析 front matter 并保存为文章
+// 标题优先使用表单字段，未提供时使用 front matter 中的 title；overwrite=true 时覆盖同名文件
 func UploadFile(c *gin.Context) {
 	requireAdmin(c)
 	if c.IsAborted() {
 		return
 	}
 
-	title := c.PostForm("title")
 	folder := c.PostForm("folder")
-	if title == "" || folder == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件标题和文件夹名称不能为空"})
+	if folder == "" {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.folder_required"))
 		return
 	}
 
-	if !isValidFolderName(folder) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件夹名称包含非法字符"})
+	if !isValidFolderPath(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
 		return
 	}
 
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件上传失败"})
+		apperror.Abort(c, apperror.ErrInvalidInput.Wrap(err).WithMessage("error.input.missing_file"))
 		return
 	}
 
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	if ext != ".md" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持上传 Markdown 文件 (.md)"})
+		apperror.Abort(c, apperror.ErrUploadInvalidType.WithMessage("error.upload.markdown_only"))
+		return
+	}
+	if file.Size > MaxFileSize {
+		apperror.Abort(c, apperror.ErrUploadTooLarge.WithMessage("error.upload.too_large", MaxFileSize>>20))
 		return
 	}
 
-	basePath := getBasePath()
-	folderPath := filepath.Join(basePath, folder)
-	if !isPathInsideBase(folderPath, basePath) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "非法路径"})
+	content, err := readUploadedFile(file)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrUploadFailed.Wrap(err))
+		return
+	}
+	meta, _, err := frontmatter.Parse(content)
+	if err != nil {
+		apperror.Abort(c, frontMatterError(err))
 		return
 	}
 
-	if err := ensureFolderExists(folderPath); err != nil {
-		zap.S().Error("确保文件夹存在失败: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "文件夹创建失败"})
+	title := c.PostForm("title")
+	if title == "" && meta != nil {
+		title = meta.Title
+	}
+	if title == "" {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.title_required"))
 		return
 	}
 
-	filePath := filepath.Join(folderPath, fmt.Sprintf("%s.md", title))
-	if _, err := os.Stat(filePath); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "文件已存在，请使用其他名称"})
+	files := markdownStorage(c)
+	filename := fmt.Sprintf("%s.md", title)
+	key := folder + "/" + filename
+	if !isValidMarkdownFileName(filename) || !files.Contains(key) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+	// 同名文件只有指定 overwrite=true 时才覆盖，覆盖时更新原文章并记录新版本
+	_, statErr := files.Stat(c.Request.Context(), key)
+	if statErr != nil && !errors.Is(statErr, storage.ErrNotFound) {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(statErr).WithMessage("error.internal.read_file"))
+		return
+	}
+	exists := statErr == nil
+	if exists && c.PostForm("overwrite") != "true" {
+		apperror.Abort(c, apperror.ErrFileExists)
 		return
 	}
 
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		zap.S().Error("文件保存失败: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "文件保存失败"})
+	author, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
+	db := c.MustGet("db").(*gorm.DB)
+	post, err := service.NewMarkdownPost(db, folder, filename, content, *author, time.Now())
+	if err != nil {
+		apperror.Abort(c, frontMatterError(err))
+		return
+	}
+	opts := service.SaveOptions{Content: content}
+	if exists {
+		// 已删除的文章在保留期内仍占用文件路径，覆盖时将其恢复，避免两篇文章指向同一文件
+		var existing models.Post
+		err := db.Unscoped().Where("source_path = ?", post.SourcePath).First(&existing).Error
+		switch {
+		case err == nil:
+			// 保留原文章的 slug 和发布状态，只替换内容
+			existing.Title, existing.Author, existing.Tags = post.Title, post.Author, post.Tags
+			existing.Summary, existing.Cover, existing.Body = post.Summary, post.Cover, post.Body
+			post, opts.Restore = &existing, existing.DeletedAt.Valid
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_post"))
+			return
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "文件上传成功", "file_path": filePath})
+	// 文章和版本在事务中保存，文件最后写入，写入失败时数据库修改一并回滚
+	if err := savePost(c, post, author, opts); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	metrics.ObserveUpload("markdown", file.Size)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   i18n.Tc(c, "msg.file_uploaded"),
+		"file_path": filepath.Join(getBasePath(c), filepath.FromSlash(key)),
+		"meta":      meta,
+		"post":      post,
+	})
+}
+
+// readUploadedFile 读取上传文件的全部内容
+func readUploadedFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
 }
 
-// GetMarkdownContent 返回指定 Markdown 文件的内容
+// frontMatterError 将 front matter 解析错误转换为 API 错误，字段错误放在 details 中
+func frontMatterError(err error) *apperror.Error {
+	var verr *frontmatter.ValidationError
+	if errors.As(err, &verr) {
+		details := make([]apperror.FieldError, len(verr.Fields))
+		for i, f := range verr.Fields {
+			details[i] = apperror.FieldError{Field: f.Field, Rule: f.Rule, Param: f.Param}
+		}
+		return apperror.ErrFrontMatterInvalid.WithDetails(details)
+	}
+	return apperror.ErrFrontMatterInvalid.Wrap(err).WithMessage("error.front_matter.syntax", err.Error())
+}
+
+// hiddenMarkdownFiles 返回文件夹中当前用户不可见的文件名：有对应的文章，但其中没有未删除的已发布文章，管理员可见全部文件
+// 没有对应文章的文件（尚未导入）保持可见；folder 须为 isValidFolderPath 校验过的规范路径
+func hiddenMarkdownFiles(c *gin.Context, folder string) (map[string]bool, error) {
+	hidden := make(map[string]bool)
+	if isAdmin(c) {
+		return hidden, nil
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var sources []string
+	err := hiddenPosts(db).
+		Where("source_path LIKE ? ESCAPE '!'", service.EscapeLike(folder)+"/%").
+		Pluck("source_path", &sources).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		hidden[strings.TrimPrefix(source, folder+"/")] = true
+	}
+	return hidden, nil
+}
+
+// markdownFileHidden 判断 Markdown 目录中 key 对应的文件对当前用户是否不可见，规则与 hiddenMarkdownFiles 相同
+func markdownFileHidden(c *gin.Context, key string) (bool, error) {
+	if isAdmin(c) {
+		return false, nil
+	}
+	var count int64
+	db := c.MustGet("db").(*gorm.DB)
+	err := hiddenPosts(db).Where("source_path = ?", key).Count(&count).Error
+	return count > 0, err
+}
+
+// hiddenPosts 查询未发布或已删除、且文件没有被其他未删除的已发布文章使用的文章
+func hiddenPosts(db *gorm.DB) *gorm.DB {
+	published := db.Model(&models.Post{}).Where("status = ? AND source_path <> ''", models.PostPublished).Select("source_path")
+	return db.Unscoped().Model(&models.Post{}).
+		Where("status <> ? OR deleted_at IS NOT NULL", models.PostPublished).
+		Where("source_path NOT IN (?)", published)
+}
+
+// readMarkdownFile 读取 Markdown 目录中 key 对应文件的全部内容
+func readMarkdownFile(c *gin.Context, key string) ([]byte, error) {
+	r, _, err := markdownStorage(c).Get(c.Request.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// GetMarkdownContent 返回指定 Markdown 文件的内容，format=html 时同时返回渲染后的 HTML
 func GetMarkdownContent(c *gin.Context) {
 	folder := c.Query("folder")
 	fileName := c.Param("file")
 
 	if folder == "" || fileName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件夹或文件名不能为空"})
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.folder_file_required"))
 		return
 	}
 
-	basePath := "uploads/test"
-	filePath := filepath.Join(basePath, folder, fileName)
+	// 可见性按规范化后的 key 判断，与实际读取的文件一致
+	folder = path.Clean(folder)
+	if !isValidFolderPath(folder) || !isValidMarkdownFileName(fileName) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
+	key := path.Join(folder, fileName)
+	if !markdownStorage(c).Contains(key) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
+		return
+	}
 
-	if !isPathInsideBase(filePath, basePath) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "非法路径"})
+	// 未发布文章对应的文件只有管理员可以读取
+	hidden, err := markdownFileHidden(c, key)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.read_file"))
+		return
+	}
+	if hidden {
+		apperror.Abort(c, apperror.ErrFileNotFound)
 		return
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readMarkdownFile(c, key)
 	if err != nil {
-		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+		if errors.Is(err, storage.ErrNotFound) {
+			// 文件夹已移动时重定向到新位置
+			if redirectCategory(c, folder, func(to string) string {
+				query := c.Request.URL.Query()
+				query.Set("folder", to)
+				return c.Request.URL.Path + "?" + query.Encode()
+			}) {
+				return
+			}
+			apperror.Abort(c, apperror.ErrFileNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件失败"})
+			apperror.Abort(c, storageError(err))
 		}
 		return
 	}
 
-	// 验证是否是纯字符串
-	response := string(content)
-	if len(response) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件内容为空"})
+	if len(content) == 0 {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.empty_file"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"content": response})
+	// content 为去掉 front matter 的正文；front matter 不合法时原样返回全文，meta 为空
+	meta, body, err := frontmatter.Parse(content)
+	if err != nil {
+		logging.FromContext(c).Warnw("解析 front matter 失败", "file", key, "error", err)
+		meta, body = nil, content
+	}
+
+	response := gin.H{"content": string(body), "meta": meta}
+	if wantHTML(c) {
+		rendered, err := markdown.Default.Render(body)
+		if err != nil {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+			return
+		}
+		response["rendered"] = rendered
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// ListMarkdownFiles 返回指定文件夹下的 Markdown 文件列表
+// ListMarkdownFiles 返回指定文件夹下的 Markdown 文件列表，folder 可以是多级路径
 func ListMarkdownFiles(c *gin.Context) {
-	folder := c.Param("folder")
+	folder := strings.Trim(c.Param("folder"), "/")
 	if folder == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件夹名称不能为空"})
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.folder_required"))
+		return
+	}
+	folder = path.Clean(folder)
+	if !isValidFolderPath(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
 		return
 	}
 
-	basePath := "uploads/test"
-	folderPath := filepath.Join(basePath, folder)
-
-	if !isPathInsideBase(folderPath, basePath) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "非法路径"})
+	files := markdownStorage(c)
+	if !files.Contains(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath)
 		return
 	}
 
-	files, err := os.ReadDir(folderPath)
+	entries, err := files.ReadDir(folder)
 	if err != nil {
-		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文件夹不存在"})
+		if errors.Is(err, storage.ErrNotFound) {
+			if redirectCategory(c, folder, func(to string) string { return "/api/markdown/files/" + escapeFolderPath(to) }) {
+				return
+			}
+			apperror.Abort(c, apperror.ErrFolderNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件夹内容失败"})
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.read_folder"))
 		}
 		return
 	}
 
+	hidden, err := hiddenMarkdownFiles(c, folder)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.read_folder"))
+		return
+	}
+
 	var mdFiles []string
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".md" {
+	for _, file := range entries {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".md" && !hidden[file.Name()] {
 			mdFiles = append(mdFiles, file.Name())
 		}
 	}
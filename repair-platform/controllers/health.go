@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"repair-platform/buildinfo"
+	"repair-platform/config"
+	"repair-platform/database"
+	"repair-platform/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout 单项就绪检查的超时时间
+const readinessTimeout = 2 * time.Second
+
+// Healthz 存活检查，进程能响应即返回 200
+// @Summary 存活检查
+// @Tags 运维
+// @Produce json
+// @Success 200 {object} map[string]string "服务存活"
+// @Router /healthz [get]
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪检查，依次检查数据库、Redis（已配置时）、上传存储和 Markdown 目录是否可用
+// @Summary 就绪检查
+// @Tags 运维
+// @Produce json
+// @Success 200 {object} map[string]interface{} "所有依赖可用"
+// @Failure 503 {object} map[string]interface{} "存在不可用的依赖"
+// @Router /readyz [get]
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	cfg := getConfig(c)
+	checks := gin.H{}
+	ready := true
+	record := func(name string, err error) {
+		if err != nil {
+			checks[name] = err.Error()
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	record("database", pingDatabase(ctx, c.MustGet("db").(*gorm.DB)))
+	if client := database.GetRedisClient(); client != nil {
+		record("redis", client.Ping(ctx).Err())
+	}
+	if cfg.Storage.Backend == config.StorageLocal {
+		record("upload_dir", checkWritable(cfg.Upload.Dir))
+	} else {
+		record("storage", checkStorage(ctx, getStorage(c)))
+	}
+	record("markdown_dir", checkWritable(cfg.Upload.MarkdownDir))
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// Version 返回构建版本信息
+// @Summary 构建信息
+// @Tags 运维
+// @Produce json
+// @Success 200 {object} buildinfo.Info "构建信息"
+// @Router /version [get]
+func Version(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
+
+// pingDatabase 检查数据库连接是否可用
+func pingDatabase(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkStorage 查询一个不存在的对象，检查存储后端能否访问，不产生写入
+func checkStorage(ctx context.Context, s storage.Storage) error {
+	if _, err := s.Stat(ctx, ".readyz"); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// checkWritable 通过创建并删除临时文件检查目录是否可写
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
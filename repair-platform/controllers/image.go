@@ -1,142 +1,162 @@
 package controllers
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"repair-platform/apperror"
+	"repair-platform/imagehost"
+	"repair-platform/imageproc"
+	"repair-platform/logging"
+	"repair-platform/metrics"
+	"repair-platform/tracing"
 	"strings"
-	"time"
 )
 
-// SMMSResponse sm.ms API 响应结构
-type SMMSResponse struct {
-	Success bool     `json:"success"`
-	Code    string   `json:"code"`
-	Message string   `json:"message"`
-	Data    SMMSData `json:"data"`
-}
-
-// SMMSData 响应数据
-type SMMSData struct {
-	Width     int    `json:"width"`
-	Height    int    `json:"height"`
-	Filename  string `json:"filename"`
-	Storename string `json:"storename"`
-	Size      int    `json:"size"`
-	Path      string `json:"path"`
-	Hash      string `json:"hash"`
-	URL       string `json:"url"`
-	Delete    string `json:"delete"`
-	Page      string `json:"page"`
-}
-
-// SmmsApiUrl sm.ms API 配置
-const SmmsApiUrl = "https://sm.ms/api/v2/upload"
-
-// SmmsApiToken 硬编码的 API Token
-const SmmsApiToken = "fnRtUbOCD3cXnO0GMbcp54EAsNEL5wXW"
-
 // MaxFileSize 文件大小限制（10MB）
 const MaxFileSize = 10 * 1024 * 1024
 
-// UploadImage 处理图片上传到 sm.ms 的请求
+// UploadImage 处理图片上传，去除 EXIF 等元数据后按 image_host.provider 保存到本地图床或上传到 sm.ms
+// @Summary 上传图片
+// @Description 图片去除元数据后重新编码。本地图床返回 /media/ 下的地址和 image.sizes 配置的派生尺寸；sm.ms 图床只保存原图并返回删除链接
+// @Tags 图片
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param image formData file true "JPG 或 PNG 图片，不超过 10MB"
+// @Success 200 {object} map[string]interface{} "image_url、width、height，以及 variants 或 delete_url"
+// @Failure 400 {object} apperror.Response "未上传图片、格式不支持或内容不是图片"
+// @Failure 413 {object} apperror.Response "文件或图片尺寸过大"
+// @Failure 502 {object} apperror.Response "第三方图床上传失败"
+// @Router /upload/image [post]
 func UploadImage(c *gin.Context) {
-	logger := c.MustGet("logger").(*zap.SugaredLogger)
+	logger := logging.FromContext(c)
 	logger.Info("开始处理图片上传请求")
 
+	user, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		logger.Warn("图片上传失败", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "图片上传失败", "details": err.Error()})
+		logger.Warnw("图片上传失败", zap.Error(err))
+		apperror.Abort(c, apperror.ErrInvalidInput.Wrap(err).WithMessage("error.input.missing_image"))
 		return
 	}
 	defer func() {
 		if cerr := file.Close(); cerr != nil {
-			logger.Warn("关闭文件失败", zap.Error(cerr))
+			logger.Warnw("关闭文件失败", zap.Error(cerr))
 		}
 	}()
 	logger.Infof("接收到文件: %s, 大小: %d bytes", header.Filename, header.Size)
 
 	// 验证文件大小
 	if header.Size > MaxFileSize {
-		logger.Warn("文件大小超过限制", zap.Int64("大小", header.Size))
-		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件大小超过限制（最大 10MB）"})
+		logger.Warnw("文件大小超过限制", zap.Int64("大小", header.Size))
+		apperror.Abort(c, apperror.ErrUploadTooLarge.WithMessage("error.upload.too_large", MaxFileSize>>20))
 		return
 	}
 
 	// 验证文件类型
 	ext := strings.ToLower(filepath.Ext(header.Filename))
 	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		logger.Warn("文件类型不支持", zap.String("文件类型", ext))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 JPG 和 PNG 格式的图片"})
+		logger.Warnw("文件类型不支持", zap.String("文件类型", ext))
+		apperror.Abort(c, apperror.ErrUploadInvalidType.WithMessage("error.upload.image_types"))
 		return
 	}
 
-	// 创建请求体
-	var b bytes.Buffer
-	writer := multipart.NewWriter(&b)
-	fw, err := writer.CreateFormFile("smfile", header.Filename)
+	processed, err := processImage(c, file, ext)
 	if err != nil {
-		logger.Error("创建表单文件失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建表单文件失败", "details": err.Error()})
+		apperror.Abort(c, err)
 		return
 	}
-	if _, err = io.Copy(fw, file); err != nil {
-		logger.Error("复制图片失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "复制图片失败", "details": err.Error()})
+
+	image, err := getImageHost(c).Upload(c.Request.Context(), header.Filename, processed)
+	switch {
+	case errors.Is(err, imagehost.ErrUpstream):
+		apperror.Abort(c, apperror.ErrImageHostFailed.Wrap(err))
 		return
-	}
-	if err = writer.Close(); err != nil {
-		logger.Error("关闭表单写入器失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭表单写入器失败", "details": err.Error()})
+	case err != nil:
+		apperror.Abort(c, apperror.ErrUploadFailed.Wrap(err))
 		return
 	}
-	logger.Info("表单数据准备完成")
 
-	// 创建 HTTP 请求
-	req, err := http.NewRequest("POST", SmmsApiUrl, &b)
-	if err != nil {
-		logger.Error("创建 HTTP 请求失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建请求失败", "details": err.Error()})
-		return
+	// 记录本地图床图片的尺寸，同一张图片重复上传时沿用已有记录
+	if rec := image.Record; rec != nil {
+		rec.UserID = user.ID
+		db := c.MustGet("db").(*gorm.DB)
+		if err := db.Where("object_key = ?", rec.ObjectKey).FirstOrCreate(rec).Error; err != nil {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_image"))
+			return
+		}
 	}
-	req.Header.Set("Authorization", SmmsApiToken)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	// 设置 HTTP 客户端超时
-	client := &http.Client{Timeout: 10 * time.Second}
+	metrics.ObserveUpload("image", header.Size)
+	logger.Infof("图片上传成功, URL: %s, 删除链接: %s", image.URL, image.DeleteURL)
+	resp := gin.H{"image_url": image.URL, "width": image.Width, "height": image.Height}
+	if len(image.Variants) > 0 {
+		resp["variants"] = image.Variants
+	}
+	if image.DeleteURL != "" {
+		resp["delete_url"] = image.DeleteURL
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	// 发送请求到 sm.ms API
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Error("上传到 sm.ms 失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "上传到 sm.ms 失败", "details": err.Error()})
-		return
+// processImage 按 image 配置去除元数据、重新编码并生成派生尺寸，返回的错误均为 *apperror.Error
+func processImage(c *gin.Context, r io.Reader, ext string) (*imageproc.Result, error) {
+	cfg := getConfig(c).Image
+	_, span := tracing.Start(c.Request.Context(), "image.process")
+	defer span.End()
+
+	res, err := imageproc.Process(r, ext, cfg)
+	switch {
+	case errors.Is(err, imageproc.ErrNotImage):
+		return nil, apperror.ErrUploadInvalidType.Wrap(err).WithMessage("error.upload.not_image")
+	case errors.Is(err, imageproc.ErrTooManyPixels):
+		return nil, apperror.ErrUploadTooLarge.Wrap(err).WithMessage("error.upload.too_many_pixels", cfg.MaxPixels/1_000_000)
+	case err != nil:
+		span.RecordError(err)
+		return nil, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.process_image")
 	}
-	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("image.width", res.Original.Width), attribute.Int("image.height", res.Original.Height), attribute.Int("image.variants", len(res.Variants)))
+	return res, nil
+}
 
-	// 解析响应数据
-	var result SMMSResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		logger.Error("解析响应失败", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析响应失败", "details": err.Error()})
+// GetMedia 提供本地图床的图片；地址随内容变化，允许浏览器和 CDN 长期缓存
+// @Summary 获取图片
+// @Tags 图片
+// @Produce image/png,image/jpeg
+// @Param path path string true "图片路径"
+// @Success 200 {file} file "图片内容"
+// @Success 304 "图片未变化"
+// @Failure 404 {object} apperror.Response "图片不存在"
+// @Router /media/{path} [get]
+func GetMedia(c *gin.Context) {
+	key := imagehost.MediaKey(c.Param("path"))
+	r, obj, err := getStorage(c).Get(c.Request.Context(), key)
+	if err != nil {
+		apperror.Abort(c, storageError(err))
 		return
 	}
-	if !result.Success {
-		logger.Warn("sm.ms API 响应错误", zap.String("错误信息", result.Message))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Message})
+	defer r.Close()
+
+	etag := imagehost.ETag(key)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
 		return
 	}
-
-	logger.Infof("图片上传成功, URL: %s, 删除链接: %s", result.Data.URL, result.Data.Delete)
-	c.JSON(http.StatusOK, gin.H{
-		"image_url":  result.Data.URL,
-		"delete_url": result.Data.Delete,
-	})
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, r, nil)
 }
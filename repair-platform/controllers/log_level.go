@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+
+	"repair-platform/apperror"
+	"repair-platform/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevelInput 调整日志级别的请求体
+type LogLevelInput struct {
+	Level string `json:"level" binding:"required"` // debug, info, warn, error
+}
+
+// GetLogLevel 返回当前日志级别
+// @Summary 获取日志级别
+// @Tags 管理员
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "当前日志级别"
+// @Router /admin/log-level [get]
+func GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logging.Level().String()})
+}
+
+// SetLogLevel 运行时调整日志级别，重启后恢复为配置文件中的级别
+// @Summary 调整日志级别
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body LogLevelInput true "新的日志级别"
+// @Success 200 {object} map[string]string "调整后的日志级别"
+// @Failure 400 {object} apperror.Response "日志级别无效"
+// @Router /admin/log-level [put]
+func SetLogLevel(c *gin.Context) {
+	var input LogLevelInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	level, err := zapcore.ParseLevel(input.Level)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.invalid_log_level"))
+		return
+	}
+
+	previous := logging.Level().Level()
+	logging.Level().SetLevel(level)
+	logging.FromContext(c).Warnw("日志级别已调整", "from", previous.String(), "to", level.String())
+	c.JSON(http.StatusOK, gin.H{"level": level.String()})
+}
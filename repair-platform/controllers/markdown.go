@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+
+	"repair-platform/apperror"
+	"repair-platform/markdown"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenderInput 渲染预览的请求体
+type RenderInput struct {
+	Content string `json:"content"`
+}
+
+// RenderMarkdown 将 Markdown 渲染为过滤后的 HTML，供编辑器预览使用
+// @Summary 渲染 Markdown
+// @Description 支持 GFM 表格、任务列表、脚注和代码高亮，返回过滤后的 HTML、目录和预计阅读时间
+// @Tags 文章
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body RenderInput true "Markdown 内容"
+// @Success 200 {object} markdown.Result "渲染结果"
+// @Failure 400 {object} apperror.Response "输入数据无效"
+// @Failure 413 {object} apperror.Response "内容过大"
+// @Router /markdown/render [post]
+func RenderMarkdown(c *gin.Context) {
+	var input RenderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	if len(input.Content) > MaxFileSize {
+		apperror.Abort(c, apperror.ErrUploadTooLarge.WithMessage("error.upload.too_large", MaxFileSize>>20))
+		return
+	}
+
+	result, err := markdown.Default.Render([]byte(input.Content))
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// wantHTML 判断请求是否要求返回渲染后的 HTML (?format=html)
+func wantHTML(c *gin.Context) bool {
+	return c.Query("format") == "html"
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/markdown"
+	"repair-platform/models"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PublishInput 发布文章的请求体，可以为空
+type PublishInput struct {
+	// PublishAt 为空时立即发布，否则在该时间定时发布，必须晚于当前时间
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PreviewLink 文章预览链接，持有链接的登录用户可以查看未发布的文章
+type PreviewLink struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// PublishPost 立即发布文章，或指定 publish_at 定时发布
+// @Summary 发布文章
+// @Tags 文章
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param input body PublishInput false "定时发布时间"
+// @Success 200 {object} APIResponse "文章已发布或已设置定时发布"
+// @Failure 400 {object} apperror.Response "定时发布时间无效"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/publish [post]
+func PublishPost(c *gin.Context) {
+	var input PublishInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			apperror.BindError(c, err)
+			return
+		}
+	}
+	if input.PublishAt != nil {
+		if err := validatePublishAt(input.PublishAt); err != nil {
+			apperror.Abort(c, err)
+			return
+		}
+	}
+
+	post, ok := changePostStatus(c, func(p *models.Post) {
+		if input.PublishAt != nil {
+			p.Schedule(*input.PublishAt)
+		} else {
+			p.SetStatus(models.PostPublished)
+		}
+	})
+	if !ok {
+		return
+	}
+
+	message := i18n.Tc(c, "msg.post_published")
+	if post.Status == models.PostScheduled {
+		message = i18n.Tc(c, "msg.post_scheduled", post.PublishAt.Format(time.RFC3339))
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: message, Data: post})
+}
+
+// UnpublishPost 撤回文章为草稿，同时取消定时发布
+// @Summary 撤回文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {object} APIResponse "文章已撤回为草稿"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/unpublish [post]
+func UnpublishPost(c *gin.Context) {
+	post, ok := changePostStatus(c, func(p *models.Post) { p.SetStatus(models.PostDraft) })
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.post_unpublished"), Data: post})
+}
+
+// ArchivePost 归档文章，归档后普通用户不可见
+// @Summary 归档文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {object} APIResponse "文章已归档"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/archive [post]
+func ArchivePost(c *gin.Context) {
+	post, ok := changePostStatus(c, func(p *models.Post) { p.SetStatus(models.PostArchived) })
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.post_archived"), Data: post})
+}
+
+// CreatePostPreview 为文章生成新的预览链接，之前的链接随之失效
+// @Summary 生成文章预览链接
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {object} APIResponse{data=PreviewLink} "预览链接"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/preview [post]
+func CreatePostPreview(c *gin.Context) {
+	token, err := generatePreviewToken()
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.generate_preview"))
+		return
+	}
+	if !updatePostPreview(c, token) {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Message: i18n.Tc(c, "msg.preview_created"),
+		Data:    PreviewLink{Token: token, URL: "/api/posts/preview/" + token},
+	})
+}
+
+// RevokePostPreview 使文章的预览链接失效
+// @Summary 撤销文章预览链接
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {object} APIResponse "预览链接已失效"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/preview [delete]
+func RevokePostPreview(c *gin.Context) {
+	if !updatePostPreview(c, "") {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.preview_revoked")})
+}
+
+// GetPostPreview 通过预览链接查看文章，不限状态，总是附带渲染结果
+// @Summary 预览文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param token path string true "预览令牌"
+// @Success 200 {object} PostDetail "文章"
+// @Failure 404 {object} apperror.Response "链接无效或已失效"
+// @Router /posts/preview/{token} [get]
+func GetPostPreview(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		apperror.Abort(c, apperror.ErrPostNotFound)
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+
+	var post models.Post
+	if err := db.Where("preview_token = ?", token).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Abort(c, apperror.ErrPostNotFound)
+		} else {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		}
+		return
+	}
+
+	detail := newPostDetail(c, &post)
+	var err error
+	if detail.Rendered, err = markdown.Default.Render([]byte(post.Body)); err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// validatePublishAt 校验定时发布时间，返回的错误均为 *apperror.Error
+func validatePublishAt(at *time.Time) error {
+	if at == nil {
+		return apperror.ErrValidationFailed.WithDetails([]apperror.FieldError{{Field: "publish_at", Rule: "required"}})
+	}
+	if !at.After(time.Now()) {
+		return apperror.ErrValidationFailed.WithDetails([]apperror.FieldError{{Field: "publish_at", Rule: "future"}})
+	}
+	return nil
+}
+
+// changePostStatus 按路径参数查询文章、修改状态并保存；失败时已写入错误响应并返回 false
+func changePostStatus(c *gin.Context, change func(*models.Post)) (*models.Post, bool) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return nil, false
+	}
+	editor, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return nil, false
+	}
+	change(post)
+
+	// 只修改状态时内容不变，不会产生新版本
+	if err := savePost(c, post, editor, service.SaveOptions{}); err != nil {
+		apperror.Abort(c, err)
+		return nil, false
+	}
+	return post, true
+}
+
+// updatePostPreview 设置文章的预览令牌，失败时已写入错误响应并返回 false
+func updatePostPreview(c *gin.Context, token string) bool {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return false
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Model(post).Update("preview_token", token).Error; err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_post"))
+		return false
+	}
+	return true
+}
+
+// generatePreviewToken 生成 32 个十六进制字符的随机预览令牌
+func generatePreviewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"repair-platform/apperror"
+	"repair-platform/diff"
+	"repair-platform/i18n"
+	"repair-platform/models"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// diffContext 比较版本时每段修改前后保留的行数
+const diffContext = 3
+
+// RevisionDiff 两个版本正文的逐行比较结果
+type RevisionDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	diff.Result
+}
+
+// ListPostRevisions 列出文章的全部版本，按版本号倒序，不包含正文
+// @Summary 文章版本列表
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {array} models.PostRevision "版本列表"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id}/revisions [get]
+func ListPostRevisions(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	revisions := []models.PostRevision{}
+	if err := db.Omit("body").Where("post_id = ?", post.ID).Order("number DESC").Find(&revisions).Error; err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_revisions"))
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetPostRevision 查看文章的某个版本
+// @Summary 查看文章版本
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param number path int true "版本号"
+// @Success 200 {object} models.PostRevision "版本内容"
+// @Failure 404 {object} apperror.Response "未找到文章或版本"
+// @Router /admin/posts/{id}/revisions/{number} [get]
+func GetPostRevision(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	revision, err := findRevision(c, post.ID, c.Param("number"))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffPostRevisions 逐行比较两个版本的正文，to 默认为最新版本，from 默认为 to 的前一个版本
+// @Summary 比较文章版本
+// @Tags 文章
+// @Produce json,plain
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param from query int false "旧版本号"
+// @Param to query int false "新版本号"
+// @Param format query string false "为 unified 时返回统一 diff 格式的纯文本"
+// @Success 200 {object} RevisionDiff "比较结果"
+// @Failure 404 {object} apperror.Response "未找到文章或版本"
+// @Router /admin/posts/{id}/diff [get]
+func DiffPostRevisions(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	to, err := findRevision(c, post.ID, c.DefaultQuery("to", "latest"))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	from, err := findRevision(c, post.ID, c.DefaultQuery("from", strconv.Itoa(max(to.Number-1, 1))))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	result := RevisionDiff{From: from.Number, To: to.Number, Result: diff.Compare(from.Body, to.Body, diffContext)}
+	if c.Query("format") == "unified" {
+		c.String(http.StatusOK, result.Unified())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RollbackPost 将文章内容恢复为指定版本，回滚本身也记录为一个新版本
+// @Summary 回滚文章版本
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param number path int true "要恢复的版本号"
+// @Success 200 {object} APIResponse "文章已回滚"
+// @Failure 404 {object} apperror.Response "未找到文章或版本"
+// @Router /admin/posts/{id}/revisions/{number}/rollback [post]
+func RollbackPost(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	revision, err := findRevision(c, post.ID, c.Param("number"))
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	editor, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	revision.Restore(post)
+	if err := savePost(c, post, editor, service.SaveOptions{RollbackOf: revision.Number}); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.post_rolled_back", revision.Number), Data: post})
+}
+
+// findRevision 查询文章的指定版本，number 为 latest 时返回最新版本，返回的错误均为 *apperror.Error
+func findRevision(c *gin.Context, postID uint, number string) (*models.PostRevision, error) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Where("post_id = ?", postID)
+	if number == "latest" {
+		query = query.Order("number DESC")
+	} else {
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, apperror.ErrRevisionNotFound
+		}
+		query = query.Where("number = ?", n)
+	}
+
+	var revision models.PostRevision
+	if err := query.First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.ErrRevisionNotFound
+		}
+		return nil, apperror.ErrInternal.Wrap(err)
+	}
+	return &revision, nil
+}
@@ -0,0 +1,445 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"repair-platform/apperror"
+	"repair-platform/frontmatter"
+	"repair-platform/i18n"
+	"repair-platform/markdown"
+	"repair-platform/models"
+	"repair-platform/search"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 文章列表分页参数
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// PostInput 创建或修改文章的请求体
+type PostInput struct {
+	Title   string   `json:"title" binding:"required,max=200"`
+	Slug    string   `json:"slug" binding:"max=200"` // 为空时根据标题生成，修改时为空表示不变
+	Folder  string   `json:"folder" binding:"max=100"`
+	Tags    []string `json:"tags"`
+	Summary string   `json:"summary" binding:"max=500"` // 为空时从正文生成
+	Cover   string   `json:"cover" binding:"max=500"`   // http(s) 地址或以 / 开头的站内路径
+	Status  string   `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	Body    string   `json:"body"`
+	// PublishAt 定时发布时间，status 为 scheduled 时必填且必须晚于当前时间
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PostDetail 文章详情，format=html 时附带渲染结果
+type PostDetail struct {
+	*models.Post
+	Rendered *markdown.Result `json:"rendered,omitempty"`
+}
+
+// PostList 文章列表响应，列表中的文章不包含正文
+type PostList struct {
+	Posts    []models.Post `json:"posts"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// ListPosts 分页查询文章，非管理员只能看到已发布的文章
+// @Summary 文章列表
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Param status query string false "状态 (draft, scheduled, published, archived)，仅管理员可用"
+// @Param folder query string false "文件夹"
+// @Param tag query string false "标签"
+// @Success 200 {object} PostList "文章列表"
+// @Failure 500 {object} apperror.Response "获取文章列表失败"
+// @Router /posts [get]
+func ListPosts(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&models.Post{})
+	if isAdmin(c) {
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+	} else {
+		query = query.Where("status = ?", models.PostPublished)
+	}
+	if folder := c.Query("folder"); folder != "" {
+		query = query.Where("folder = ?", folder)
+	}
+	if tag := c.Query("tag"); tag != "" {
+		query = withTag(query, tag)
+	}
+
+	result, err := paginatePosts(c, query)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// paginatePosts 按分页参数查询文章，按发布时间倒序，不包含正文和非管理员不可见的文件路径，返回的错误均为 *apperror.Error
+func paginatePosts(c *gin.Context, query *gorm.DB) (PostList, error) {
+	page, pageSize := parsePage(c)
+	result := PostList{Posts: []models.Post{}, Page: page, PageSize: pageSize}
+	if err := query.Count(&result.Total).Error; err != nil {
+		return result, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_posts")
+	}
+	err := query.Omit("body").
+		Order("published_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&result.Posts).Error
+	if err != nil {
+		return result, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_posts")
+	}
+	for i := range result.Posts {
+		hidePostSource(c, &result.Posts[i])
+	}
+	return result, nil
+}
+
+// newPostDetail 生成文章详情响应，非管理员看不到文件路径
+func newPostDetail(c *gin.Context, post *models.Post) PostDetail {
+	hidePostSource(c, post)
+	return PostDetail{Post: post}
+}
+
+// hidePostSource 文章也通过公开接口和预览链接返回，只有管理员能看到服务器上的文件路径
+func hidePostSource(c *gin.Context, post *models.Post) {
+	if !isAdmin(c) {
+		post.SourcePath = ""
+	}
+}
+
+// GetPost 查看单篇文章，未发布的文章只有管理员可见
+// @Summary 查看文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param format query string false "为 html 时附带渲染后的 HTML、目录和阅读时间"
+// @Success 200 {object} PostDetail "文章"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /posts/{id} [get]
+func GetPost(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	if post.Status != models.PostPublished && !isAdmin(c) {
+		apperror.Abort(c, apperror.ErrPostNotFound)
+		return
+	}
+
+	detail := newPostDetail(c, post)
+	if wantHTML(c) {
+		if detail.Rendered, err = markdown.Default.Render([]byte(post.Body)); err != nil {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// CreatePost 创建文章，作者为当前用户
+// @Summary 创建文章
+// @Tags 文章
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post body PostInput true "文章内容"
+// @Success 201 {object} APIResponse "文章创建成功"
+// @Failure 400 {object} apperror.Response "输入数据无效"
+// @Failure 409 {object} apperror.Response "slug 已被使用"
+// @Router /admin/posts [post]
+func CreatePost(c *gin.Context) {
+	var input PostInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	author, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	post := models.Post{AuthorID: author.ID, Author: author.Username, Status: models.PostDraft}
+	if err := applyPostInput(c, &post, input); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := savePost(c, &post, author, service.SaveOptions{}); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{Message: i18n.Tc(c, "msg.post_created"), Data: post})
+}
+
+// UpdatePost 修改文章，内容有变化时记录新版本，从文件导入的文章同时写回文件，修改文件夹时文件随之移动
+// @Summary 修改文章
+// @Tags 文章
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param post body PostInput true "文章内容"
+// @Success 200 {object} APIResponse "文章更新成功"
+// @Failure 400 {object} apperror.Response "输入数据无效"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Failure 409 {object} apperror.Response "slug 已被使用"
+// @Router /admin/posts/{id} [put]
+func UpdatePost(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	var input PostInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	oldFolder := post.Folder
+	if err := applyPostInput(c, post, input); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	editor, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	var opts service.SaveOptions
+	if post.Folder != oldFolder {
+		if opts.MoveFrom, err = movePostSource(post); err != nil {
+			apperror.Abort(c, err)
+			return
+		}
+	}
+	if err := savePost(c, post, editor, opts); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.post_updated"), Data: post})
+}
+
+// DeletePost 删除文章（软删除），Markdown 源文件在文章被彻底清理时一并删除
+// @Summary 删除文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Success 200 {object} APIResponse "文章已删除"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /admin/posts/{id} [delete]
+func DeletePost(c *gin.Context) {
+	post, err := findPost(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Delete(post).Error; err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.delete_post"))
+		return
+	}
+	search.Default.Remove(search.KindPost, post.ID)
+
+	c.JSON(http.StatusOK, APIResponse{Message: i18n.Tc(c, "msg.post_deleted")})
+}
+
+// ImportPosts 将 Markdown 目录中尚未导入的文件导入为文章，作者为当前管理员
+// @Summary 从 Markdown 目录导入文章
+// @Tags 文章
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} APIResponse "导入结果"
+// @Failure 500 {object} apperror.Response "导入失败"
+// @Router /admin/posts/import [post]
+func ImportPosts(c *gin.Context) {
+	author, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	result, err := service.ImportMarkdownTree(c.Request.Context(), db, markdownStorage(c), *author)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.import_posts"))
+		return
+	}
+	if result.Imported > 0 {
+		rebuildSearchIndex(c)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: i18n.Tc(c, "msg.posts_imported", result.Imported, result.Skipped),
+		Data:    result,
+	})
+}
+
+// savePost 保存文章、记录版本并同步 Markdown 文件和检索索引，返回的错误均为 *apperror.Error
+func savePost(c *gin.Context, post *models.Post, editor *models.User, opts service.SaveOptions) error {
+	db := c.MustGet("db").(*gorm.DB)
+	if err := service.SavePost(db, markdownStorage(c), post, *editor, opts); err != nil {
+		return fileOperationError(err, "error.internal.save_post")
+	}
+	search.Default.Put(search.PostDocument(post))
+	return nil
+}
+
+// movePostSource 将导入文章的文件路径改到文章新的文件夹中，文件名不变，返回原路径供 SavePost 移动文件
+// 导入的文章必须位于某个文件夹中；没有对应文件的文章不做任何事。返回的错误均为 *apperror.Error
+func movePostSource(post *models.Post) (string, error) {
+	if post.SourcePath == "" {
+		return "", nil
+	}
+	if post.Folder == "" {
+		return "", apperror.ErrInvalidInput.WithMessage("error.input.source_folder_required")
+	}
+	from := post.SourcePath
+	post.SourcePath = path.Join(post.Folder, path.Base(from))
+	return from, nil
+}
+
+// findPost 按路径参数 id 查询文章，返回的错误均为 *apperror.Error
+func findPost(c *gin.Context) (*models.Post, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, apperror.ErrPostNotFound
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	var post models.Post
+	if err := db.First(&post, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.ErrPostNotFound
+		}
+		return nil, apperror.ErrInternal.Wrap(err)
+	}
+	return &post, nil
+}
+
+// applyPostInput 将请求内容写入文章，并校验文件夹和 slug，返回的错误均为 *apperror.Error
+func applyPostInput(c *gin.Context, post *models.Post, input PostInput) error {
+	if input.Folder != "" && !isValidFolderPath(input.Folder) {
+		return apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name")
+	}
+	if input.Cover != "" && !frontmatter.IsValidCover(input.Cover) {
+		return apperror.ErrValidationFailed.WithDetails([]apperror.FieldError{{Field: "cover", Rule: "url"}})
+	}
+	if input.Status == models.PostScheduled {
+		if err := validatePublishAt(input.PublishAt); err != nil {
+			return err
+		}
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	switch {
+	case input.Slug != "":
+		// 指定的 slug 不自动加后缀，冲突时由调用方修改
+		slug := models.Slugify(input.Slug)
+		taken, err := models.UniqueSlug(db, slug, post.ID)
+		if err != nil {
+			return apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_post")
+		}
+		if taken != slug {
+			return apperror.ErrPostSlugExists
+		}
+		post.Slug = slug
+	case post.Slug == "":
+		slug, err := models.UniqueSlug(db, models.Slugify(input.Title), post.ID)
+		if err != nil {
+			return apperror.ErrInternal.Wrap(err).WithMessage("error.internal.save_post")
+		}
+		post.Slug = slug
+	}
+
+	post.Title = input.Title
+	post.Folder = input.Folder
+	post.Tags = normalizeTags(input.Tags)
+	post.Body = input.Body
+	post.Summary = input.Summary
+	post.Cover = input.Cover
+	if post.Summary == "" {
+		post.Summary = service.Summarize(input.Body)
+	}
+	switch input.Status {
+	case "":
+	case models.PostScheduled:
+		post.Schedule(*input.PublishAt)
+	default:
+		post.SetStatus(input.Status)
+	}
+	return nil
+}
+
+// normalizeTags 去掉标签两端空白，忽略空标签和重复标签
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// parsePage 解析分页参数，非法值使用默认值
+func parsePage(c *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err = strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+// isAdmin 判断当前用户是否为管理员
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("role") == models.RoleAdmin
+}
+
+// withTag 筛选带有指定标签的文章，标签以 JSON 数组保存，按带引号的元素匹配
+// 标签按保存时相同的方式编码，< > & 和引号等字符在 JSON 中是转义后的形式
+func withTag(query *gorm.DB, tag string) *gorm.DB {
+	encoded, _ := json.Marshal(tag)
+	return query.Where("tags LIKE ? ESCAPE '!'", "%"+service.EscapeLike(string(encoded))+"%")
+}
+
+// inFolder 筛选文件夹为 folder 或其子文件夹的文章
+func inFolder(query *gorm.DB, folder string) *gorm.DB {
+	return query.Where("folder = ? OR folder LIKE ? ESCAPE '!'", folder, service.EscapeLike(folder)+"/%")
+}
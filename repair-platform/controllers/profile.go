@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateLocaleInput 修改语言偏好的请求体
+type UpdateLocaleInput struct {
+	Locale string `json:"locale" binding:"required"` // zh-CN 或 en
+}
+
+// UpdateLocale 修改当前用户的语言偏好
+// 语言偏好保存在 JWT 中，因此同时返回一个新的令牌，客户端应替换旧令牌
+// @Summary 修改语言偏好
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body UpdateLocaleInput true "语言偏好"
+// @Success 200 {object} APIResponse "语言偏好已更新，返回新的 JWT 令牌"
+// @Failure 400 {object} apperror.Response "不支持的语言"
+// @Failure 404 {object} apperror.Response "用户未找到"
+// @Router /profile/locale [put]
+func UpdateLocale(c *gin.Context) {
+	var input UpdateLocaleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.BindError(c, err)
+		return
+	}
+	locale, ok := i18n.Parse(input.Locale)
+	if !ok {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.invalid_locale"))
+		return
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Model(user).Update("locale", string(locale)).Error; err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.update_user"))
+		return
+	}
+
+	authCfg := getConfig(c).Auth
+	token, err := models.GenerateJWT(user.Username, user.Role, string(locale), authCfg.JWTSecret, authCfg.TokenTTL.Std())
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.generate_token"))
+		return
+	}
+
+	i18n.SetLocale(c, locale)
+	c.JSON(http.StatusOK, APIResponse{
+		Message: i18n.Tc(c, "msg.locale_updated"),
+		Data:    gin.H{"locale": locale, "token": token},
+	})
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"repair-platform/apperror"
+	"repair-platform/config"
+	"repair-platform/feed"
+	"repair-platform/markdown"
+	"repair-platform/models"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPublicPost 按 slug 查看已发布的文章，不需要登录，草稿等未发布的文章一律返回 404
+// @Summary 查看公开文章
+// @Tags 公开
+// @Produce json
+// @Param slug path string true "文章 slug"
+// @Param format query string false "为 html 时附带渲染后的 HTML、目录和阅读时间"
+// @Success 200 {object} PostDetail "文章"
+// @Failure 404 {object} apperror.Response "未找到文章"
+// @Router /public/posts/{slug} [get]
+func GetPublicPost(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var post models.Post
+	err := db.Where("slug = ? AND status = ?", c.Param("slug"), models.PostPublished).First(&post).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Abort(c, apperror.ErrPostNotFound)
+		} else {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		}
+		return
+	}
+	detail := newPostDetail(c, &post)
+	if wantHTML(c) {
+		if detail.Rendered, err = markdown.Default.Render([]byte(post.Body)); err != nil {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// RSSFeed 输出全站最近发布文章的 RSS 2.0 订阅源
+// @Summary RSS 订阅源
+// @Tags 公开
+// @Produce xml
+// @Success 200 {string} string "RSS 2.0 文档"
+// @Router /rss.xml [get]
+func RSSFeed(c *gin.Context) {
+	serveFeed(c, "", feedRSS)
+}
+
+// AtomFeed 输出全站最近发布文章的 Atom 1.0 订阅源
+// @Summary Atom 订阅源
+// @Tags 公开
+// @Produce xml
+// @Success 200 {string} string "Atom 1.0 文档"
+// @Router /atom.xml [get]
+func AtomFeed(c *gin.Context) {
+	serveFeed(c, "", feedAtom)
+}
+
+// FolderFeed 输出文件夹（包含子文件夹）的订阅源，路径以 rss.xml 或 atom.xml 结尾，文件夹已移动时重定向
+// @Summary 文件夹订阅源
+// @Tags 公开
+// @Produce xml
+// @Param path path string true "文件夹路径加 /rss.xml 或 /atom.xml，如 guides/phone/rss.xml"
+// @Success 200 {string} string "RSS 2.0 或 Atom 1.0 文档"
+// @Success 301 "文件夹已移动"
+// @Failure 404 {object} apperror.Response "文件夹不存在"
+// @Router /public/folders/{path} [get]
+func FolderFeed(c *gin.Context) {
+	folder, format := path.Split(strings.Trim(c.Param("path"), "/"))
+	folder = strings.TrimSuffix(folder, "/")
+	if format != feedRSS && format != feedAtom {
+		apperror.Abort(c, apperror.ErrNotFound)
+		return
+	}
+	if !isValidFolderPath(folder) {
+		apperror.Abort(c, apperror.ErrInvalidPath.WithMessage("error.path.invalid_folder_name"))
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	tree, err := service.CategoryTree(db, nil, true)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_categories"))
+		return
+	}
+	if service.Find(tree, folder) == nil {
+		if redirectCategory(c, folder, func(to string) string { return "/api/public/folders/" + escapeFolderPath(to) + "/" + format }) {
+			return
+		}
+		apperror.Abort(c, apperror.ErrFolderNotFound)
+		return
+	}
+	serveFeed(c, folder, format)
+}
+
+// Sitemap 输出包含站点首页和全部已发布文章的 sitemap.xml
+// @Summary 站点地图
+// @Tags 公开
+// @Produce xml
+// @Success 200 {string} string "sitemap.xml 文档"
+// @Router /sitemap.xml [get]
+func Sitemap(c *gin.Context) {
+	site := getConfig(c).Site
+	db := c.MustGet("db").(*gorm.DB)
+
+	var posts []models.Post
+	err := db.Select("slug", "updated_at").
+		Where("status = ?", models.PostPublished).
+		Order("published_at DESC, id DESC").
+		Find(&posts).Error
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_posts"))
+		return
+	}
+
+	urls := make([]feed.URL, 0, len(posts)+1)
+	urls = append(urls, feed.URL{Loc: siteURL(site, "/")})
+	for _, p := range posts {
+		urls = append(urls, feed.URL{Loc: postURL(site, p.Slug), LastMod: p.UpdatedAt})
+	}
+	writeXML(c, "application/xml; charset=utf-8", func() ([]byte, error) { return feed.Sitemap(urls) })
+}
+
+// 订阅源格式，与路径中的文件名一致
+const (
+	feedRSS  = "rss.xml"
+	feedAtom = "atom.xml"
+)
+
+// serveFeed 生成文件夹 folder（为空时为全站）的订阅源并写入响应
+func serveFeed(c *gin.Context, folder, format string) {
+	f, ok := buildFeed(c, folder)
+	if !ok {
+		return
+	}
+	if format == feedAtom {
+		writeXML(c, "application/atom+xml; charset=utf-8", f.Atom)
+	} else {
+		writeXML(c, "application/rss+xml; charset=utf-8", f.RSS)
+	}
+}
+
+// buildFeed 查询最近发布的文章并生成订阅源，folder 不为空时只包含该文件夹及其子文件夹的文章
+// 失败时已写入错误响应并返回 false
+func buildFeed(c *gin.Context, folder string) (*feed.Feed, bool) {
+	site := getConfig(c).Site
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Where("status = ?", models.PostPublished)
+	title := site.Title
+	if folder != "" {
+		query = inFolder(query, folder)
+		title = site.Title + " - " + folder
+	}
+	var posts []models.Post
+	if err := query.Order("published_at DESC, id DESC").Limit(site.FeedLimit).Find(&posts).Error; err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_posts"))
+		return nil, false
+	}
+
+	f := &feed.Feed{
+		Title:       title,
+		Description: site.Description,
+		Link:        siteURL(site, "/"),
+		// 订阅源由前端站点同域反向代理，自身地址使用站点地址加请求路径
+		Self:  siteURL(site, c.Request.URL.Path),
+		Items: make([]feed.Item, 0, len(posts)),
+	}
+	for _, p := range posts {
+		rendered, err := markdown.Default.Render([]byte(p.Body))
+		if err != nil {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.render_markdown"))
+			return nil, false
+		}
+		published := p.CreatedAt
+		if p.PublishedAt != nil {
+			published = *p.PublishedAt
+		}
+		f.Items = append(f.Items, feed.Item{
+			Title:     p.Title,
+			Link:      postURL(site, p.Slug),
+			Author:    p.Author,
+			Summary:   p.Summary,
+			Content:   rendered.HTML,
+			Tags:      p.Tags,
+			Published: published,
+			Updated:   p.UpdatedAt,
+		})
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+	return f, true
+}
+
+// writeXML 生成 XML 文档并写入响应
+func writeXML(c *gin.Context, contentType string, generate func() ([]byte, error)) {
+	body, err := generate()
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// siteURL 拼接站点根地址和路径
+func siteURL(site config.SiteConfig, p string) string {
+	return strings.TrimSuffix(site.BaseURL, "/") + p
+}
+
+// postURL 返回文章在前端站点上的绝对地址
+func postURL(site config.SiteConfig, slug string) string {
+	return siteURL(site, site.PostPath+url.PathEscape(slug))
+}
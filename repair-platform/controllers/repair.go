@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"io"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/imageproc"
+	"repair-platform/metrics"
 	"repair-platform/models"
+	"repair-platform/service"
 	"strings"
 	"time"
 )
@@ -18,7 +21,6 @@ import (
 // 文件上传配置
 const (
 	MaxFileSize2   = 5 << 20 // 5MB
-	UploadDir      = "./uploads/"
 	AllowedFormats = "jpg,jpeg,png,pdf"
 )
 
@@ -37,25 +39,33 @@ type RepairRequestForm struct {
 // @Param description formData string true "维修请求描述"
 // @Param file formData file false "上传的文件（图片或PDF）"
 // @Success 200 {object} map[string]string "维修请求提交成功"
-// @Failure 400 {object} map[string]string "输入数据无效或文件上传失败"
-// @Failure 500 {object} map[string]string "提交维修请求失败"
+// @Failure 400 {object} apperror.Response "输入数据无效或文件上传失败"
+// @Failure 500 {object} apperror.Response "提交维修请求失败"
 // @Router /repair_requests [post]
 func SubmitRepairRequest(c *gin.Context) {
 	var form RepairRequestForm
 
 	// 绑定请求数据
 	if err := c.ShouldBind(&form); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "输入数据无效"})
+		apperror.BindError(c, err)
+		return
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	var request models.RepairRequest
+	request.UserID = user.ID
 	request.Description = form.Description
 
 	// 处理文件上传
+	var image *models.Image
 	if form.File != nil {
-		if err := handleFileUpload(c, form.File, &request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("文件上传失败: %v", err)})
+		if image, err = handleFileUpload(c, form.File, &request); err != nil {
+			apperror.Abort(c, err)
 			return
 		}
 	}
@@ -63,59 +73,78 @@ func SubmitRepairRequest(c *gin.Context) {
 	// 获取数据库连接
 	db := c.MustGet("db").(*gorm.DB)
 
-	// 创建新的维修请求
-	if err := db.Create(&request).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交维修请求失败"})
+	// 创建新的维修请求和图片记录，失败时删除已保存的文件
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		if image == nil {
+			return nil
+		}
+		image.UserID = user.ID
+		return tx.Create(image).Error
+	})
+	if err != nil {
+		if image != nil {
+			_ = service.DeleteImageObjects(c.Request.Context(), getStorage(c), image)
+		} else if request.ImageURL != "" {
+			_ = getStorage(c).Delete(c.Request.Context(), request.ImageURL)
+		}
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.submit_repair"))
 		return
 	}
+	syncRepairIndex(c, request.ID)
 
 	// 返回提交成功消息
-	c.JSON(http.StatusOK, gin.H{"message": "维修请求提交成功"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "msg.repair_submitted")})
 }
 
-// handleFileUpload 处理文件上传，包含类型检查、大小限制和路径安全性
-func handleFileUpload(c *gin.Context, file *multipart.FileHeader, request *models.RepairRequest) error {
+// handleFileUpload 处理文件上传，包含类型检查、大小限制和路径安全性，返回的错误均为 *apperror.Error
+// 图片去除 EXIF 后连同派生尺寸一起保存，返回的图片记录由调用方入库；PDF 原样保存，返回 nil
+func handleFileUpload(c *gin.Context, file *multipart.FileHeader, request *models.RepairRequest) (*models.Image, error) {
 	// 检查文件大小
 	if file.Size > MaxFileSize2 {
-		return errors.New("文件大小超过限制")
+		return nil, apperror.ErrUploadTooLarge.WithMessage("error.upload.too_large", MaxFileSize2>>20)
 	}
 
 	// 检查文件类型
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !strings.Contains(AllowedFormats, ext[1:]) {
-		return errors.New("文件格式不支持，仅允许上传 " + AllowedFormats)
-	}
-
-	// 确保上传目录存在
-	if err := os.MkdirAll(UploadDir, os.ModePerm); err != nil {
-		return errors.New("无法创建上传目录")
+	if ext == "" || !strings.Contains(AllowedFormats, ext[1:]) {
+		return nil, apperror.ErrUploadInvalidType.WithMessage("error.upload.allowed_formats", AllowedFormats)
 	}
 
 	// 打开上传的文件
 	src, err := file.Open()
 	if err != nil {
-		return err
+		return nil, apperror.ErrUploadFailed.Wrap(err)
 	}
 	defer src.Close()
 
-	// 保存文件
-	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-	filePath := filepath.Join(UploadDir, filename)
+	// 保存到上传存储的根目录，内容类型按已校验的扩展名推断，不使用客户端声明的类型
+	stem := fmt.Sprintf("%d", time.Now().UnixNano())
+	if !imageproc.Supported(ext) {
+		key := stem + ext
+		if err := getStorage(c).Put(c.Request.Context(), key, src, file.Size, ""); err != nil {
+			return nil, apperror.ErrUploadFailed.Wrap(err)
+		}
+		request.ImageURL = key
+		metrics.ObserveUpload("repair", file.Size)
+		return nil, nil
+	}
 
-	dst, err := os.Create(filePath)
+	processed, err := processImage(c, src, ext)
 	if err != nil {
-		return errors.New("文件保存失败")
+		return nil, err
 	}
-	defer dst.Close()
-
-	// 将文件内容复制到目标文件
-	if _, err := io.Copy(dst, src); err != nil {
-		return errors.New("文件保存失败")
+	image, err := service.StoreImage(c.Request.Context(), getStorage(c), stem, models.ImageSourceRepair, processed)
+	if err != nil {
+		return nil, apperror.ErrUploadFailed.Wrap(err)
 	}
 
-	// 将文件路径保存到维修请求中
-	request.ImageURL = filePath
-	return nil
+	// 将原图在存储中的 key 保存到维修请求中
+	request.ImageURL = image.ObjectKey
+	metrics.ObserveUpload("repair", file.Size)
+	return image, nil
 }
 
 // AdminListRepairRequests 管理员查看维修请求列表
@@ -124,7 +153,7 @@ func handleFileUpload(c *gin.Context, file *multipart.FileHeader, request *model
 // @Tags 维修请求
 // @Produce json
 // @Success 200 {array} models.RepairRequest "维修请求列表"
-// @Failure 500 {object} map[string]string "检索维修请求失败"
+// @Failure 500 {object} apperror.Response "检索维修请求失败"
 // @Router /repair_requests [get]
 func AdminListRepairRequests(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
@@ -132,7 +161,7 @@ func AdminListRepairRequests(c *gin.Context) {
 
 	// 查找所有维修请求
 	if err := db.Find(&requests).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "检索维修请求失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_repairs"))
 		return
 	}
 
@@ -149,9 +178,9 @@ func AdminListRepairRequests(c *gin.Context) {
 // @Param id path string true "维修请求ID"
 // @Param request body models.RepairRequest true "更新的维修请求内容"
 // @Success 200 {object} map[string]string "维修请求更新成功"
-// @Failure 400 {object} map[string]string "输入数据无效"
-// @Failure 404 {object} map[string]string "未找到维修请求"
-// @Failure 500 {object} map[string]string "更新维修请求失败"
+// @Failure 400 {object} apperror.Response "输入数据无效"
+// @Failure 404 {object} apperror.Response "未找到维修请求"
+// @Failure 500 {object} apperror.Response "更新维修请求失败"
 // @Router /repair_requests/{id} [put]
 func AdminUpdateRepairRequest(c *gin.Context) {
 	id := c.Param("id")
@@ -162,22 +191,59 @@ func AdminUpdateRepairRequest(c *gin.Context) {
 
 	// 根据ID查找维修请求
 	if err := db.Where("id = ?", id).First(&request).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "未找到维修请求"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Abort(c, apperror.ErrRepairNotFound)
+		} else {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		}
 		return
 	}
 
 	// 绑定JSON数据到维修请求模型
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "输入数据无效"})
+		apperror.BindError(c, err)
 		return
 	}
 
 	// 更新维修请求
 	if err := db.Save(&request).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新维修请求失败"})
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.update_repair"))
 		return
 	}
+	syncRepairIndex(c, request.ID)
 
 	// 返回更新成功消息
-	c.JSON(http.StatusOK, gin.H{"message": "维修请求更新成功"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "msg.repair_updated")})
+}
+
+// GetRepairAttachment 管理员查看维修请求的附件，重定向到有效期为 storage.signed_url_ttl 的签名下载地址
+// @Summary 查看维修请求附件
+// @Tags 维修请求
+// @Security BearerAuth
+// @Param id path string true "维修请求ID"
+// @Success 302 "重定向到签名下载地址"
+// @Failure 404 {object} apperror.Response "未找到维修请求或没有附件"
+// @Router /admin/repair_requests/{id}/attachment [get]
+func GetRepairAttachment(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var request models.RepairRequest
+	if err := db.Where("id = ?", c.Param("id")).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Abort(c, apperror.ErrRepairNotFound)
+		} else {
+			apperror.Abort(c, apperror.ErrInternal.Wrap(err))
+		}
+		return
+	}
+	if request.ImageURL == "" {
+		apperror.Abort(c, apperror.ErrFileNotFound)
+		return
+	}
+
+	u, err := getStorage(c).SignedURL(c.Request.Context(), models.AttachmentKey(request.ImageURL), getConfig(c).Storage.SignedURLTTL.Std())
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.read_file"))
+		return
+	}
+	c.Redirect(http.StatusFound, u)
 }
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"repair-platform/apperror"
+	"repair-platform/logging"
+	"repair-platform/search"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SearchResponse 检索响应，命中的标题和摘要为已转义并用 <mark> 高亮的 HTML
+type SearchResponse struct {
+	Hits     []search.Hit `json:"hits"`
+	Total    int          `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// Search 全文检索文章和报修请求，结果按当前用户的角色过滤
+// @Summary 全文检索
+// @Description 检索文章标题、正文、标签以及报修描述和反馈评论，中文按相邻两字切分
+// @Tags 检索
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "检索关键词"
+// @Param type query string false "文档类型 (post, repair)，为空时检索全部"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} SearchResponse "检索结果"
+// @Failure 400 {object} apperror.Response "检索关键词为空或类型无效"
+// @Router /search [get]
+func Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		apperror.Abort(c, apperror.ErrInvalidInput.WithMessage("error.input.query_required"))
+		return
+	}
+
+	var kinds []search.Kind
+	switch kind := search.Kind(c.Query("type")); kind {
+	case "":
+	case search.KindPost, search.KindRepair:
+		kinds = []search.Kind{kind}
+	default:
+		apperror.Abort(c, apperror.ErrInvalidInput.WithDetails([]apperror.FieldError{{Field: "type", Rule: "oneof", Param: "post repair"}}))
+		return
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	result := search.Default.Search(search.Query{
+		Text:   text,
+		Kinds:  kinds,
+		Viewer: search.Viewer{UserID: user.ID, Role: user.Role},
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	c.JSON(http.StatusOK, SearchResponse{Hits: result.Hits, Total: result.Total, Page: page, PageSize: pageSize})
+}
+
+// syncRepairIndex 重新索引报修请求，失败只记录日志，定期重建会修正索引
+func syncRepairIndex(c *gin.Context, id uint) {
+	db := c.MustGet("db").(*gorm.DB)
+	if err := search.Default.SyncRepair(c.Request.Context(), db, id); err != nil {
+		logging.FromContext(c).Errorw("更新报修请求检索索引失败", "repair_id", id, "error", err)
+	}
+}
+
+// rebuildSearchIndex 从数据库重建检索索引，用于批量导入等无法逐条同步的场景
+func rebuildSearchIndex(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	if _, err := search.Default.Rebuild(c.Request.Context(), db); err != nil {
+		logging.FromContext(c).Errorw("重建检索索引失败", "error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"repair-platform/apperror"
+	"repair-platform/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSignedFile 凭本地或内存存储签发的下载地址下载文件，签名无效或已过期时返回 403；S3 后端的地址直接指向对象存储
+// @Summary 下载文件
+// @Tags 文件
+// @Produce octet-stream
+// @Param key path string true "文件在存储中的 key"
+// @Param expires query int true "过期时间（Unix 秒）"
+// @Param signature query string true "签名"
+// @Success 200 {file} file "文件内容"
+// @Failure 403 {object} apperror.Response "签名无效或已过期"
+// @Failure 404 {object} apperror.Response "文件不存在"
+// @Router /files/{key} [get]
+func GetSignedFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	store := getStorage(c)
+	verifier, ok := store.(storage.Verifier)
+	if !ok {
+		apperror.Abort(c, apperror.ErrNotFound)
+		return
+	}
+	if err := verifier.VerifySignedURL(key, c.Query("expires"), c.Query("signature")); err != nil {
+		apperror.Abort(c, apperror.ErrSignedURLInvalid.Wrap(err))
+		return
+	}
+
+	r, obj, err := store.Get(c.Request.Context(), key)
+	if err != nil {
+		apperror.Abort(c, storageError(err))
+		return
+	}
+	defer r.Close()
+	// 下载地址因人而异，不允许共享缓存；禁止浏览器按内容猜测类型
+	c.Header("Cache-Control", "private")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, r, nil)
+}
+
+// storageError 将存储的错误转换为 API 错误，对象不存在或 key 非法时视为文件不存在
+func storageError(err error) *apperror.Error {
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return apperror.ErrFileNotFound
+	}
+	return apperror.ErrInternal.Wrap(err).WithMessage("error.internal.read_file")
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+
+	"repair-platform/apperror"
+	"repair-platform/models"
+	"repair-platform/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TagPage 标签页面：使用该标签的已发布文章
+type TagPage struct {
+	Tag string `json:"tag"`
+	PostList
+}
+
+// ListTags 返回已发布文章使用的全部标签及文章数
+// @Summary 标签列表
+// @Tags 公开
+// @Produce json
+// @Success 200 {array} service.TagCount "标签列表，按文章数降序"
+// @Router /public/tags [get]
+func ListTags(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	tags, err := service.PublishedTags(db)
+	if err != nil {
+		apperror.Abort(c, apperror.ErrInternal.Wrap(err).WithMessage("error.internal.list_tags"))
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetTag 标签页面，分页列出带有该标签的已发布文章
+// @Summary 标签页面
+// @Tags 公开
+// @Produce json
+// @Param tag path string true "标签"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页数量，最大 100"
+// @Success 200 {object} TagPage "文章列表"
+// @Router /public/tags/{tag} [get]
+func GetTag(c *gin.Context) {
+	tag := c.Param("tag")
+	db := c.MustGet("db").(*gorm.DB)
+	query := withTag(db.Model(&models.Post{}).Where("status = ?", models.PostPublished), tag)
+	posts, err := paginatePosts(c, query)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, TagPage{Tag: tag, PostList: posts})
+}
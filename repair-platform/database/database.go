@@ -6,15 +6,17 @@ import (
 	"os"
 	"time"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
-	"repair-platform/models"
+	"repair-platform/config"
 )
 
-// InitDB 初始化数据库连接并自动迁移模型
-func InitDB() (*gorm.DB, error) {
+// InitDB 初始化数据库连接，表结构由 migrations 包管理
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	// 自定义日志配置
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
@@ -25,8 +27,13 @@ func InitDB() (*gorm.DB, error) {
 		},
 	)
 
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// 配置数据库连接选项
-	db, err := gorm.Open(sqlite.Open("repair_platform.db"), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: newLogger,
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // 使用单数表名
@@ -36,15 +43,51 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// 自动迁移数据库结构
-	if err := db.AutoMigrate(&models.User{}, &models.RepairRequest{}, &models.Feedback{}, &models.PasswordResetToken{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	// 配置连接池
+	if err := configurePool(db, cfg); err != nil {
+		return nil, err
 	}
 
-	log.Println("Database connection and migration successful.")
+	log.Println("Database connection successful.")
 	return db, nil
 }
 
+// openDialector 根据配置的驱动选择 GORM 方言
+func openDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	case config.DriverMySQL:
+		return mysql.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
+
+// configurePool 设置底层 sql.DB 的连接池参数，零值表示使用驱动默认值
+func configurePool(db *gorm.DB, cfg config.DatabaseConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve generic database object: %w", err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Std())
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Std())
+	}
+	return nil
+}
+
 // CloseDB 关闭数据库连接
 func CloseDB(db *gorm.DB) {
 	sqlDB, err := db.DB()
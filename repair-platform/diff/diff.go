@@ -0,0 +1,248 @@
+// Package diff 按行比较两段文本，使用 Myers 算法得到最短编辑序列
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op 行的变化类型
+type Op string
+
+// 行的变化类型，取值与统一 diff 格式的行首字符一致
+const (
+	Equal  Op = " "
+	Insert Op = "+"
+	Delete Op = "-"
+)
+
+// Line 编辑序列中的一行，Old/New 为该行在旧文本和新文本中的行号（从 1 开始），不存在时为 0
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+	Old  int    `json:"old,omitempty"`
+	New  int    `json:"new,omitempty"`
+}
+
+// Hunk 一段连续的修改及其上下文，起始行号和行数的含义与统一 diff 格式一致
+type Hunk struct {
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Result 比较结果
+type Result struct {
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Hunks   []Hunk `json:"hunks"`
+}
+
+// Compare 比较两段文本，每段修改前后保留 context 行上下文
+func Compare(a, b string, context int) Result {
+	lines := Lines(splitLines(a), splitLines(b))
+	result := Result{Hunks: Hunks(lines, context)}
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			result.Added++
+		case Delete:
+			result.Removed++
+		}
+	}
+	return result
+}
+
+// splitLines 按行拆分，忽略末尾换行，统一 \r\n
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Lines 返回把 a 变为 b 的最短编辑序列，包含未修改的行
+func Lines(a, b []string) []Line {
+	// 相同的开头和结尾不参与搜索
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, Line{Op: Equal, Text: a[i], Old: i + 1, New: i + 1})
+	}
+	for _, l := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		if l.Old > 0 {
+			l.Old += prefix
+		}
+		if l.New > 0 {
+			l.New += prefix
+		}
+		lines = append(lines, l)
+	}
+	for i := suffix; i > 0; i-- {
+		lines = append(lines, Line{Op: Equal, Text: a[len(a)-i], Old: len(a) - i + 1, New: len(b) - i + 1})
+	}
+	return lines
+}
+
+// myers 计算编辑序列，trace 只保存每一步用到的对角线范围，内存为 O(D²)
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		// 保存第 d 步开始前 k ∈ [-d-1, d+1] 的状态
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// 从终点回溯，倒序生成编辑序列
+	var reversed []Line
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := trace[d] // prev[k+d+1] 为第 d 步开始前对角线 k 的位置
+		at := func(k int) int { return prev[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{Op: Equal, Text: a[x-1], Old: x, New: y})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, Line{Op: Insert, Text: b[y-1], New: y})
+			} else {
+				reversed = append(reversed, Line{Op: Delete, Text: a[x-1], Old: x})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	lines := make([]Line, len(reversed))
+	for i, l := range reversed {
+		lines[len(reversed)-1-i] = l
+	}
+	return lines
+}
+
+// Hunks 将编辑序列分为若干段，每段修改前后保留 context 行上下文，相距不超过 2*context 行的修改合并为一段
+func Hunks(lines []Line, context int) []Hunk {
+	hunks := []Hunk{}
+	i := 0
+	for i < len(lines) {
+		// 找到下一处修改
+		for i < len(lines) && lines[i].Op == Equal {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// 向后扩展到修改之间的相同行超过 2*context 为止
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Op == Equal {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk 创建 lines[start:end] 对应的段，行数为 0 时起始位置为前一行的行号，与 diff -u 一致
+func newHunk(lines []Line, start, end int) Hunk {
+	h := Hunk{Lines: lines[start:end]}
+	for _, l := range lines[:start] {
+		if l.Op != Insert {
+			h.OldStart++
+		}
+		if l.Op != Delete {
+			h.NewStart++
+		}
+	}
+	for _, l := range h.Lines {
+		if l.Op != Insert {
+			h.OldLines++
+		}
+		if l.Op != Delete {
+			h.NewLines++
+		}
+	}
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
+
+// Unified 将比较结果格式化为统一 diff 格式，不含文件头
+func (r Result) Unified() string {
+	var b strings.Builder
+	for _, h := range r.Hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+		for _, l := range h.Lines {
+			b.WriteString(string(l.Op))
+			b.WriteString(l.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
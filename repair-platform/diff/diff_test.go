@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	r := Compare(a, b, 1)
+	if r.Added != 2 || r.Removed != 1 {
+		t.Fatalf("expected +2 -1, got +%d -%d", r.Added, r.Removed)
+	}
+	want := "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -10,1 +10,2 @@\n j\n+k\n"
+	if got := r.Unified(); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLinesShortestEdit(t *testing.T) {
+	a := strings.Split("A B C A B B A", " ")
+	b := strings.Split("C B A B A C", " ")
+	lines := Lines(a, b)
+
+	edits := 0
+	var old, new []string
+	for _, l := range lines {
+		if l.Op != Equal {
+			edits++
+		}
+		if l.Op != Insert {
+			old = append(old, l.Text)
+		}
+		if l.Op != Delete {
+			new = append(new, l.Text)
+		}
+	}
+	if edits != 5 {
+		t.Errorf("expected 5 edits, got %d: %+v", edits, lines)
+	}
+	if strings.Join(old, " ") != strings.Join(a, " ") || strings.Join(new, " ") != strings.Join(b, " ") {
+		t.Errorf("edit script does not reproduce inputs: %+v", lines)
+	}
+}
+
+func TestCompareEmpty(t *testing.T) {
+	if r := Compare("same\n", "same", 3); len(r.Hunks) != 0 {
+		t.Errorf("expected no hunks, got %+v", r.Hunks)
+	}
+	want := "@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Compare("", "x\ny\n", 3).Unified(); got != want {
+		t.Errorf("unexpected diff %q", got)
+	}
+}
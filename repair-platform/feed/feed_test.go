@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedDocuments(t *testing.T) {
+	at := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	f := &Feed{
+		Title: "站点",
+		Link:  "https://example.com/",
+		Self:  "https://example.com/rss.xml",
+		Items: []Item{{
+			Title:     "A & B",
+			Link:      "https://example.com/posts/a",
+			Content:   "<p>正文</p>",
+			Tags:      []string{"go"},
+			Published: at,
+			Updated:   at,
+		}},
+		Updated: at,
+	}
+
+	for name, generate := range map[string]func() ([]byte, error){"rss": f.RSS, "atom": f.Atom} {
+		out, err := generate()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// 输出必须是合法的 XML，标题中的特殊字符已转义
+		if err := xml.Unmarshal(out, new(struct{})); err != nil {
+			t.Fatalf("%s: invalid xml: %v\n%s", name, err, out)
+		}
+		if !strings.Contains(string(out), "A &amp; B") || !strings.Contains(string(out), "https://example.com/posts/a") {
+			t.Fatalf("%s: unexpected output:\n%s", name, out)
+		}
+	}
+
+	rss, _ := f.RSS()
+	if !strings.Contains(string(rss), "<content:encoded><![CDATA[<p>正文</p>]]></content:encoded>") {
+		t.Fatalf("rss should carry html content:\n%s", rss)
+	}
+	atom, _ := f.Atom()
+	if !strings.Contains(string(atom), `<content type="html">&lt;p&gt;正文&lt;/p&gt;</content>`) || !strings.Contains(string(atom), "2026-10-19T08:00:00Z") {
+		t.Fatalf("unexpected atom output:\n%s", atom)
+	}
+
+	sitemap, err := Sitemap([]URL{{Loc: "https://example.com/"}, {Loc: "https://example.com/posts/a", LastMod: at}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(sitemap), "<url>") != 2 || strings.Count(string(sitemap), "<lastmod>") != 1 {
+		t.Fatalf("unexpected sitemap:\n%s", sitemap)
+	}
+}
@@ -0,0 +1,306 @@
+// Package frontmatter 解析 Markdown 文件开头的 YAML (---) 或 TOML (+++) 元数据
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 字段长度限制，与文章表的列宽一致
+const (
+	maxTitleLength   = 200
+	maxAuthorLength  = 100
+	maxSummaryLength = 500
+	maxCoverLength   = 500
+	maxTagLength     = 50
+)
+
+// dateLayouts 字符串形式的 date 支持的格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ErrUnterminated 表示找到了开始分隔符但没有结束分隔符
+var ErrUnterminated = errors.New("front matter is not terminated")
+
+// Meta 是 front matter 中支持的字段，未知字段会被忽略
+type Meta struct {
+	Title   string     `json:"title,omitempty"`
+	Date    *time.Time `json:"date,omitempty"`
+	Tags    []string   `json:"tags,omitempty"`
+	Author  string     `json:"author,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+	Draft   bool       `json:"draft"`
+	Cover   string     `json:"cover,omitempty"` // 封面图片，http(s) 地址或以 / 开头的站内路径
+}
+
+// FieldError 描述单个字段不合法的原因，Rule/Param 与请求参数校验的规则名一致
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+// ValidationError 表示 front matter 语法正确但字段不合法
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Rule
+	}
+	return "invalid front matter: " + strings.Join(parts, ", ")
+}
+
+// Parse 拆分 front matter 和正文；没有 front matter 时返回 nil 和原内容
+// 语法错误返回普通错误，字段不合法返回 *ValidationError
+func Parse(content []byte) (*Meta, []byte, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	var delim string
+	var unmarshal func([]byte, interface{}) error
+	switch {
+	case hasDelimiter(content, "---"):
+		delim, unmarshal = "---", yaml.Unmarshal
+	case hasDelimiter(content, "+++"):
+		delim, unmarshal = "+++", toml.Unmarshal
+	default:
+		return nil, content, nil
+	}
+
+	header, body, ok := split(content, delim)
+	if !ok {
+		return nil, content, ErrUnterminated
+	}
+
+	raw := map[string]interface{}{}
+	if err := unmarshal(header, &raw); err != nil {
+		return nil, content, fmt.Errorf("invalid front matter: %w", err)
+	}
+	meta, err := decode(raw)
+	if err != nil {
+		return nil, content, err
+	}
+	return meta, body, nil
+}
+
+// hasDelimiter 判断内容是否以单独一行的分隔符开头
+func hasDelimiter(content []byte, delim string) bool {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	return string(bytes.TrimRight(line, " \t\r")) == delim
+}
+
+// split 返回两个分隔符之间的内容和之后的正文
+func split(content []byte, delim string) (header, body []byte, ok bool) {
+	_, rest, _ := bytes.Cut(content, []byte("\n"))
+	offset := 0
+	for offset < len(rest) {
+		line, next, found := bytes.Cut(rest[offset:], []byte("\n"))
+		if string(bytes.TrimRight(line, " \t\r")) == delim {
+			body = []byte{}
+			if found {
+				body = bytes.TrimLeft(next, "\r\n")
+			}
+			return rest[:offset], body, true
+		}
+		if !found {
+			break
+		}
+		offset += len(line) + 1
+	}
+	return nil, nil, false
+}
+
+// decode 将解析出的键值转换为 Meta 并校验
+func decode(raw map[string]interface{}) (*Meta, error) {
+	meta := &Meta{}
+	var fields []FieldError
+	fail := func(field, rule, param string) {
+		fields = append(fields, FieldError{Field: field, Rule: rule, Param: param})
+	}
+
+	for key, value := range raw {
+		switch strings.ToLower(key) {
+		case "title":
+			meta.Title = stringField(value, key, maxTitleLength, fail)
+		case "author":
+			meta.Author = stringField(value, key, maxAuthorLength, fail)
+		case "summary", "description":
+			meta.Summary = stringField(value, key, maxSummaryLength, fail)
+		case "cover", "image":
+			meta.Cover = stringField(value, key, maxCoverLength, fail)
+			if meta.Cover != "" && !IsValidCover(meta.Cover) {
+				fail(key, "url", "")
+				meta.Cover = ""
+			}
+		case "date":
+			date, ok := parseDate(value)
+			if !ok {
+				fail(key, "datetime", "")
+				continue
+			}
+			meta.Date = &date
+		case "tags":
+			meta.Tags = tagsField(value, key, fail)
+		case "draft":
+			draft, ok := value.(bool)
+			if !ok {
+				fail(key, "boolean", "")
+				continue
+			}
+			meta.Draft = draft
+		}
+	}
+
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+		return nil, &ValidationError{Fields: fields}
+	}
+	return meta, nil
+}
+
+// stringField 读取字符串字段，去掉两端空白并检查长度
+func stringField(value interface{}, key string, max int, fail func(field, rule, param string)) string {
+	s, ok := scalarString(value)
+	if !ok {
+		fail(key, "string", "")
+		return ""
+	}
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) > max {
+		fail(key, "max", fmt.Sprint(max))
+		return ""
+	}
+	return s
+}
+
+// tagsField 读取标签，支持字符串列表或逗号分隔的字符串，重复和空标签会被忽略
+func tagsField(value interface{}, key string, fail func(field, rule, param string)) []string {
+	var items []string
+	switch v := value.(type) {
+	case string:
+		items = strings.Split(v, ",")
+	case []interface{}:
+		for _, item := range v {
+			s, ok := scalarString(item)
+			if !ok {
+				fail(key, "string", "")
+				return nil
+			}
+			items = append(items, s)
+		}
+	default:
+		fail(key, "string", "")
+		return nil
+	}
+
+	tags := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, tag := range items {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			fail(key, "max", fmt.Sprint(maxTagLength))
+			return nil
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// scalarString 接受字符串和数字，例如 title: 2024 会被解析为数字
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int, int64, uint64, float64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// parseDate 支持 YAML/TOML 原生时间和常见的字符串格式，不带时区的时间按本地时区处理
+func parseDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case toml.LocalDate:
+		return v.AsTime(time.Local), true
+	case toml.LocalDateTime:
+		return v.AsTime(time.Local), true
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// IsValidCover 判断封面地址是否合法，只能是 http(s) 地址或站内绝对路径
+func IsValidCover(cover string) bool {
+	if strings.HasPrefix(cover, "/") && !strings.HasPrefix(cover, "//") {
+		return true
+	}
+	u, err := url.Parse(cover)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// yamlMeta 决定 Format 输出的字段顺序，空字段省略
+type yamlMeta struct {
+	Title   string   `yaml:"title,omitempty"`
+	Date    string   `yaml:"date,omitempty"`
+	Tags    []string `yaml:"tags,omitempty,flow"`
+	Author  string   `yaml:"author,omitempty"`
+	Summary string   `yaml:"summary,omitempty"`
+	Draft   bool     `yaml:"draft,omitempty"`
+	Cover   string   `yaml:"cover,omitempty"`
+}
+
+// Format 生成带 YAML front matter 的 Markdown 文件内容，结果可以被 Parse 还原
+func Format(meta *Meta, body []byte) ([]byte, error) {
+	if meta == nil {
+		return body, nil
+	}
+	m := yamlMeta{
+		Title:   meta.Title,
+		Tags:    meta.Tags,
+		Author:  meta.Author,
+		Summary: meta.Summary,
+		Draft:   meta.Draft,
+		Cover:   meta.Cover,
+	}
+	if meta.Date != nil {
+		m.Date = meta.Date.Format(time.RFC3339)
+	}
+	header, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package frontmatter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := "---\ntitle: Hello\ndate: 2024-07-10\ntags: [go, \" go \", 2024]\ndraft: true\ncover: /media/a.png\nlayout: post\n---\n\n# Body\n"
+	meta, body, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if meta.Title != "Hello" || !meta.Draft || meta.Cover != "/media/a.png" {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+	if meta.Date == nil || meta.Date.Format("2006-01-02") != "2024-07-10" {
+		t.Errorf("unexpected date %v", meta.Date)
+	}
+	if len(meta.Tags) != 2 || meta.Tags[0] != "go" || meta.Tags[1] != "2024" {
+		t.Errorf("unexpected tags %v", meta.Tags)
+	}
+	if string(body) != "# Body\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	content := "+++\r\ntitle = \"Hello\"\r\ndate = 2024-07-10T21:18:26+08:00\r\ntags = \"a, b\"\r\n+++\r\nbody"
+	meta, body, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if meta.Title != "Hello" || len(meta.Tags) != 2 || string(body) != "body" {
+		t.Errorf("unexpected result %+v %q", meta, body)
+	}
+	if meta.Date == nil || !meta.Date.Equal(time.Date(2024, 7, 10, 13, 18, 26, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", meta.Date)
+	}
+}
+
+func TestParseWithoutFrontMatter(t *testing.T) {
+	content := "# Title\n\n---\n\ntext"
+	meta, body, err := Parse([]byte(content))
+	if err != nil || meta != nil || string(body) != content {
+		t.Fatalf("expected content to be returned unchanged, got %+v %q %v", meta, body, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, err := Parse([]byte("---\ntitle: a\n")); !errors.Is(err, ErrUnterminated) {
+		t.Errorf("expected ErrUnterminated, got %v", err)
+	}
+	if _, _, err := Parse([]byte("---\ntitle: [\n---\n")); err == nil {
+		t.Error("expected syntax error")
+	}
+
+	_, _, err := Parse([]byte("---\ndate: yesterday\ndraft: \"no\"\ncover: javascript:alert(1)\n---\n"))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := []FieldError{{Field: "cover", Rule: "url"}, {Field: "date", Rule: "datetime"}, {Field: "draft", Rule: "boolean"}}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("unexpected fields %+v", verr.Fields)
+	}
+	for i, f := range want {
+		if verr.Fields[i] != f {
+			t.Errorf("field %d: expected %+v, got %+v", i, f, verr.Fields[i])
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	date := time.Date(2024, 7, 10, 21, 18, 26, 0, time.UTC)
+	meta := &Meta{Title: "标题: 冒号", Date: &date, Tags: []string{"go", "博客"}, Summary: "摘要", Draft: true}
+	content, err := Format(meta, []byte("# Body\n"))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	got, body, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v\n%s", err, content)
+	}
+	if got.Title != meta.Title || !got.Date.Equal(date) || len(got.Tags) != 2 || got.Summary != "摘要" || !got.Draft || string(body) != "# Body\n" {
+		t.Errorf("round trip mismatch: %+v %q", got, body)
+	}
+}
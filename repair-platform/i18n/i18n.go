@@ -0,0 +1,107 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// Locale 是支持的语言区域
+type Locale string
+
+// 支持的语言区域，Default 为缺少翻译或无法协商时使用的语言
+const (
+	ZhCN    Locale = "zh-CN"
+	En      Locale = "en"
+	Default        = ZhCN
+)
+
+// supported 的顺序与 matcher 中的语言标签一一对应
+var supported = []Locale{ZhCN, En}
+
+var matcher = language.NewMatcher([]language.Tag{language.SimplifiedChinese, language.English})
+
+//go:embed locales/*.yaml
+var localeFiles embed.FS
+
+// catalog 按语言区域保存消息模板，键为 locales/*.yaml 中的扁平键
+var catalog = map[Locale]map[string]string{}
+
+func init() {
+	for _, l := range supported {
+		data, err := localeFiles.ReadFile(path.Join("locales", string(l)+".yaml"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", l, err))
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", l, err))
+		}
+		catalog[l] = messages
+	}
+}
+
+// Parse 解析客户端给出的语言区域，只接受受支持的值（不区分大小写，zh 视为 zh-CN）
+func Parse(s string) (Locale, bool) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "-")) {
+	case "zh-cn", "zh", "zh-hans", "zh-hans-cn":
+		return ZhCN, true
+	case "en", "en-us", "en-gb":
+		return En, true
+	}
+	return "", false
+}
+
+// Match 根据 Accept-Language 头选择最合适的语言区域
+func Match(acceptLanguage string) Locale {
+	if acceptLanguage == "" {
+		return Default
+	}
+	_, index := language.MatchStrings(matcher, acceptLanguage)
+	return supported[index]
+}
+
+// T 返回指定语言的消息，args 按 fmt 格式填入模板
+// 缺少翻译时回退到默认语言，仍然缺少时返回键本身，便于发现遗漏
+func T(l Locale, key string, args ...interface{}) string {
+	msg, ok := catalog[l][key]
+	if !ok {
+		msg, ok = catalog[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// localeKey 上下文中保存语言区域的键
+const localeKey = "locale"
+
+// FromContext 返回当前请求使用的语言区域
+func FromContext(c *gin.Context) Locale {
+	if v, ok := c.Get(localeKey); ok {
+		if l, ok := v.(Locale); ok {
+			return l
+		}
+	}
+	return Default
+}
+
+// SetLocale 设置当前请求使用的语言区域，并通过 Content-Language 告知客户端
+func SetLocale(c *gin.Context, l Locale) {
+	c.Set(localeKey, l)
+	c.Header("Content-Language", string(l))
+}
+
+// Tc 使用当前请求的语言区域返回消息
+func Tc(c *gin.Context, key string, args ...interface{}) string {
+	return T(FromContext(c), key, args...)
+}
@@ -0,0 +1,45 @@
+package i18n
+
+import "testing"
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for key := range catalog[Default] {
+		for _, l := range supported {
+			if _, ok := catalog[l][key]; !ok {
+				t.Errorf("%s: missing key %q", l, key)
+			}
+		}
+	}
+	for _, l := range supported {
+		for key := range catalog[l] {
+			if _, ok := catalog[Default][key]; !ok {
+				t.Errorf("%s: key %q missing from default catalog", l, key)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]Locale{
+		"":                          Default,
+		"en-US,en;q=0.9":            En,
+		"zh-CN,zh;q=0.9,en;q=0.8":   ZhCN,
+		"fr-FR,en;q=0.5":            En,
+		"de-DE":                     Default,
+		"en;q=0.3,zh-Hans-CN;q=0.7": ZhCN,
+	}
+	for header, want := range cases {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestTFallsBack(t *testing.T) {
+	if got := T(En, "error.upload.too_large", 5); got != "File is too large (max 5MB)" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if got := T(En, "no.such.key"); got != "no.such.key" {
+		t.Errorf("expected key for missing message, got %q", got)
+	}
+}
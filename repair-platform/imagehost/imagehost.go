@@ -0,0 +1,47 @@
+package imagehost
+
+import (
+	"context"
+	"errors"
+
+	"repair-platform/config"
+	"repair-platform/imageproc"
+	"repair-platform/models"
+	"repair-platform/storage"
+)
+
+// ErrUpstream 第三方图床无法访问或返回失败
+var ErrUpstream = errors.New("image host request failed")
+
+// Image 上传成功的图片
+type Image struct {
+	URL       string    // 原图的访问地址
+	DeleteURL string    // 第三方图床提供的删除链接，本地图床为空
+	Width     int       // 原图宽度
+	Height    int       // 原图高度
+	Variants  []Variant // 本地图床保存的派生尺寸，第三方图床为空
+	// Record 本地图床保存的对象，由调用方入库；第三方图床为 nil
+	Record *models.Image
+}
+
+// Variant 派生尺寸的访问地址和宽高
+type Variant struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// Provider 图床，上传的是已经去除元数据并重新编码的图片
+type Provider interface {
+	Upload(ctx context.Context, filename string, img *imageproc.Result) (Image, error)
+}
+
+// New 按配置创建图床，本地图床的图片保存在 store 中
+func New(cfg config.ImageHostConfig, store storage.Storage) Provider {
+	if cfg.Provider == config.ImageHostSMMS {
+		return NewSMMS(cfg.SMMSAPIURL, cfg.SMMSToken)
+	}
+	return NewLocal(store, cfg.MediaURL)
+}
@@ -0,0 +1,87 @@
+package imagehost
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"repair-platform/imageproc"
+	"repair-platform/storage"
+)
+
+func TestLocal(t *testing.T) {
+	img := &imageproc.Result{
+		Original: imageproc.Variant{Name: "original", Ext: ".png", ContentType: "image/png", Width: 4, Height: 2, Data: []byte("png")},
+		Variants: []imageproc.Variant{{Name: "thumbnail", Ext: ".webp", ContentType: "image/webp", Width: 2, Height: 1, Data: []byte("webp")}},
+	}
+	store := storage.NewMemory(nil)
+	l := NewLocal(store, "https://api.example.com/media/")
+
+	out, err := l.Upload(context.Background(), "Photo.PNG", img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := out.Record
+	if rec == nil || !strings.HasPrefix(rec.ObjectKey, KeyPrefix) || out.URL != "https://api.example.com/media/"+MediaPath(rec.ObjectKey) || out.Width != 4 {
+		t.Fatalf("unexpected image: %+v", out)
+	}
+	if MediaKey("/"+MediaPath(rec.ObjectKey)) != rec.ObjectKey {
+		t.Fatalf("media path does not round-trip: %s", rec.ObjectKey)
+	}
+	if len(out.Variants) != 1 || !strings.HasSuffix(out.Variants[0].URL, "_thumbnail.webp") || out.Variants[0].Width != 2 {
+		t.Fatalf("unexpected variants: %+v", out.Variants)
+	}
+	for _, key := range rec.Keys() {
+		if _, err := store.Stat(context.Background(), key); err != nil {
+			t.Fatalf("expected %s in storage: %v", key, err)
+		}
+	}
+	if obj, _ := store.Stat(context.Background(), rec.Variants[0].Key); obj.ContentType != "image/webp" {
+		t.Fatalf("unexpected content type %q", obj.ContentType)
+	}
+
+	// 相同内容得到相同的 key
+	again, err := l.Upload(context.Background(), "other.png", img)
+	if err != nil || again.Record.ObjectKey != rec.ObjectKey {
+		t.Fatalf("expected same key, got %+v %v", again, err)
+	}
+}
+
+func TestSMMS(t *testing.T) {
+	var success bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" {
+			t.Errorf("missing token")
+		}
+		file, header, err := r.FormFile("smfile")
+		if err != nil {
+			t.Errorf("missing smfile: %v", err)
+			return
+		}
+		body, _ := io.ReadAll(file)
+		if header.Filename != "a.png" || string(body) != "png" {
+			t.Errorf("unexpected file %s: %q", header.Filename, body)
+		}
+		if !success {
+			w.Write([]byte(`{"success":false,"message":"Image upload repeated limit"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"url":"https://i.loli.net/a.png","delete":"https://sm.ms/delete/x"}}`))
+	}))
+	defer server.Close()
+
+	s := NewSMMS(server.URL, "token")
+	img := &imageproc.Result{Original: imageproc.Variant{Ext: ".png", Width: 1, Height: 1, Data: []byte("png")}}
+	if _, err := s.Upload(context.Background(), "a.png", img); !errors.Is(err, ErrUpstream) {
+		t.Fatalf("expected ErrUpstream, got %v", err)
+	}
+	success = true
+	out, err := s.Upload(context.Background(), "a.png", img)
+	if err != nil || out.URL != "https://i.loli.net/a.png" || out.DeleteURL != "https://sm.ms/delete/x" || out.Width != 1 {
+		t.Fatalf("unexpected result: %+v %v", out, err)
+	}
+}
@@ -0,0 +1,65 @@
+package imagehost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+
+	"repair-platform/imageproc"
+	"repair-platform/models"
+	"repair-platform/service"
+	"repair-platform/storage"
+)
+
+// KeyPrefix 本地图床的图片在存储中的 key 前缀，只有该前缀下的对象通过 /media/ 公开
+const KeyPrefix = "images/"
+
+// Local 将图片和派生尺寸保存到存储后端，文件名取原图内容的哈希，同一张图片只保存一份，地址不会指向不同的内容
+type Local struct {
+	store   storage.Storage
+	baseURL string
+}
+
+// NewLocal 创建本地图床，图片地址为 baseURL 加上 MediaPath 返回的路径
+func NewLocal(store storage.Storage, baseURL string) *Local {
+	return &Local{store: store, baseURL: baseURL}
+}
+
+// Upload 保存原图和全部派生尺寸
+func (l *Local) Upload(ctx context.Context, filename string, img *imageproc.Result) (Image, error) {
+	sum := sha256.Sum256(img.Original.Data)
+	name := hex.EncodeToString(sum[:16])
+	rec, err := service.StoreImage(ctx, l.store, KeyPrefix+name[:2]+"/"+name, models.ImageSourceHost, img)
+	if err != nil {
+		return Image{}, err
+	}
+
+	out := Image{URL: l.url(rec.ObjectKey), Width: rec.Width, Height: rec.Height, Record: rec}
+	for _, v := range rec.Variants {
+		out.Variants = append(out.Variants, Variant{Name: v.Name, URL: l.url(v.Key), ContentType: v.ContentType, Width: v.Width, Height: v.Height})
+	}
+	return out, nil
+}
+
+// url 返回对象的访问地址
+func (l *Local) url(key string) string {
+	return l.baseURL + storage.EscapeKey(MediaPath(key))
+}
+
+// MediaPath 返回图片在 /media/ 下的路径
+func MediaPath(key string) string {
+	return strings.TrimPrefix(key, KeyPrefix)
+}
+
+// MediaKey 将 /media/ 下的路径转换为存储中的 key
+func MediaKey(mediaPath string) string {
+	return KeyPrefix + strings.TrimPrefix(mediaPath, "/")
+}
+
+// ETag 图片的实体标签；文件名即内容的哈希，不需要读取内容
+func ETag(key string) string {
+	name := path.Base(key)
+	return `"` + strings.TrimSuffix(name, path.Ext(name)) + `"`
+}
@@ -0,0 +1,106 @@
+package imagehost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"repair-platform/imageproc"
+	"repair-platform/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SMMSResponse sm.ms API 响应结构
+type SMMSResponse struct {
+	Success bool     `json:"success"`
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Data    SMMSData `json:"data"`
+}
+
+// SMMSData 响应数据
+type SMMSData struct {
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Filename  string `json:"filename"`
+	Storename string `json:"storename"`
+	Size      int    `json:"size"`
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+	URL       string `json:"url"`
+	Delete    string `json:"delete"`
+	Page      string `json:"page"`
+}
+
+// SMMS 将图片上传到 sm.ms
+type SMMS struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// NewSMMS 创建 sm.ms 图床，请求超时 10 秒并生成客户端 span
+func NewSMMS(apiURL, token string) *SMMS {
+	return &SMMS{apiURL: apiURL, token: token, client: tracing.HTTPClient(10 * time.Second)}
+}
+
+// Upload 上传处理后的原图，sm.ms 不保存派生尺寸；网络错误和 sm.ms 返回的失败均包装为 ErrUpstream
+func (s *SMMS) Upload(ctx context.Context, filename string, img *imageproc.Result) (Image, error) {
+	o := img.Original
+	b, contentType, err := buildSMMSBody(ctx, bytes.NewReader(o.Data), filename, int64(len(o.Data)))
+	if err != nil {
+		return Image{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL, b)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", s.token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %w", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	var result SMMSResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Image{}, fmt.Errorf("%w: bad response: %w", ErrUpstream, err)
+	}
+	if !result.Success {
+		return Image{}, fmt.Errorf("%w: %s", ErrUpstream, result.Message)
+	}
+	return Image{URL: result.Data.URL, DeleteURL: result.Data.Delete, Width: o.Width, Height: o.Height}, nil
+}
+
+// buildSMMSBody 将上传的图片复制为 sm.ms 需要的 multipart 请求体
+// 单独记录 span，以便区分本地复制和远程上传的耗时
+func buildSMMSBody(ctx context.Context, file io.Reader, filename string, size int64) (*bytes.Buffer, string, error) {
+	_, span := tracing.Start(ctx, "image.build_multipart", trace.WithAttributes(attribute.Int64("image.size", size)))
+	defer span.End()
+
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+	fw, err := writer.CreateFormFile("smfile", filename)
+	if err != nil {
+		span.RecordError(err)
+		return nil, "", fmt.Errorf("创建表单文件失败: %w", err)
+	}
+	if _, err = io.Copy(fw, file); err != nil {
+		span.RecordError(err)
+		return nil, "", fmt.Errorf("复制图片失败: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		span.RecordError(err)
+		return nil, "", fmt.Errorf("关闭表单写入器失败: %w", err)
+	}
+	return &b, writer.FormDataContentType(), nil
+}
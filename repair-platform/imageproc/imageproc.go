@@ -0,0 +1,154 @@
+// Package imageproc 处理上传的图片：解码后按 EXIF 方向摆正，去除全部元数据重新编码，并生成派生尺寸
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"repair-platform/config"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var (
+	// ErrNotImage 文件无法解码，或内容与扩展名声明的图片格式不符
+	ErrNotImage = errors.New("file content is not the declared image type")
+	// ErrTooManyPixels 图片像素数超过 image.max_pixels
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// formats 允许的扩展名及其对应的解码格式
+var formats = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+}
+
+// Variant 重新编码后的一种尺寸和格式
+type Variant struct {
+	Name        string // 原图为 original，派生尺寸为配置中的名称
+	Ext         string // 带点的扩展名，如 .jpg
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Result 处理结果
+type Result struct {
+	Original Variant   // 去除元数据、必要时缩小后的原图，格式与上传时相同
+	Variants []Variant // 按配置顺序排列的派生尺寸，开启 WebP 时每个尺寸紧跟其 WebP 版本
+}
+
+// Supported 判断扩展名是否为可处理的图片格式
+func Supported(ext string) bool {
+	_, ok := formats[strings.ToLower(ext)]
+	return ok
+}
+
+// Process 读取 ext 格式的图片并按 cfg 处理；内容与扩展名不符时返回 ErrNotImage
+func Process(r io.Reader, ext string, cfg config.ImageConfig) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	ext = strings.ToLower(ext)
+	format, ok := formats[ext]
+	if !ok {
+		return nil, ErrNotImage
+	}
+	// 先读取尺寸，避免解码超大图片耗尽内存
+	conf, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return nil, ErrNotImage
+	}
+	if conf.Width*conf.Height > cfg.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	img := toNRGBA(src)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	img = fit(img, cfg.MaxDimension, cfg.MaxDimension)
+
+	res := &Result{}
+	if res.Original, err = encode("original", img, format, cfg); err != nil {
+		return nil, err
+	}
+	for _, size := range cfg.Sizes {
+		scaled := fit(img, size.Width, size.Height)
+		v, err := encode(size.Name, scaled, format, cfg)
+		if err != nil {
+			return nil, err
+		}
+		res.Variants = append(res.Variants, v)
+		if cfg.WebP {
+			if v, err = encode(size.Name, scaled, "webp", cfg); err != nil {
+				return nil, err
+			}
+			res.Variants = append(res.Variants, v)
+		}
+	}
+	return res, nil
+}
+
+// encode 按格式编码图片，标准库和 WebP 编码器都不会写入任何元数据
+func encode(name string, img *image.NRGBA, format string, cfg config.ImageConfig) (Variant, error) {
+	var buf bytes.Buffer
+	v := Variant{Name: name, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	var err error
+	switch format {
+	case "jpeg":
+		v.Ext, v.ContentType = ".jpg", "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: cfg.JPEGQuality})
+	case "png":
+		v.Ext, v.ContentType = ".png", "image/png"
+		err = png.Encode(&buf, img)
+	case "webp":
+		v.Ext, v.ContentType = ".webp", "image/webp"
+		err = encodeWebP(&buf, img)
+	}
+	if err != nil {
+		return Variant{}, fmt.Errorf("failed to encode %s %s: %w", name, format, err)
+	}
+	v.Data = buf.Bytes()
+	return v, nil
+}
+
+// toNRGBA 将任意图片转换为以 (0, 0) 为原点的 NRGBA
+func toNRGBA(src image.Image) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	xdraw.Draw(dst, dst.Bounds(), src, b.Min, xdraw.Src)
+	return dst
+}
+
+// fit 将图片等比缩小到 maxW×maxH 以内，为 0 的边不限制，不放大
+func fit(img *image.NRGBA, maxW, maxH int) *image.NRGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	scale := 1.0
+	if maxW > 0 && w > maxW {
+		scale = float64(maxW) / float64(w)
+	}
+	if maxH > 0 && float64(h)*scale > float64(maxH) {
+		scale = float64(maxH) / float64(h)
+	}
+	if scale == 1 {
+		return img
+	}
+	nw, nh := max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5))
+	dst := image.NewNRGBA(image.Rect(0, 0, nw, nh))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
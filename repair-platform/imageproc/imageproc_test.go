@@ -0,0 +1,149 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"repair-platform/config"
+
+	"golang.org/x/image/webp"
+)
+
+var testConfig = config.ImageConfig{
+	MaxDimension: 64,
+	MaxPixels:    1 << 20,
+	JPEGQuality:  90,
+	WebP:         true,
+	Sizes:        []config.ImageSize{{Name: "thumbnail", Width: 16, Height: 16}, {Name: "wide", Width: 40}},
+}
+
+func TestWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{1, 1}, {2, 1}, {3, 7}, {64, 48}, {600, 5}} {
+		for _, alpha := range []bool{false, true} {
+			img := image.NewNRGBA(image.Rect(0, 0, size[0], size[1]))
+			for y := 0; y < size[1]; y++ {
+				for x := 0; x < size[0]; x++ {
+					c := color.NRGBA{R: uint8(x * 3), G: uint8(y * 5), B: uint8(rng.Intn(256)), A: 255}
+					if alpha {
+						c.A = uint8(rng.Intn(256))
+					}
+					img.SetNRGBA(x, y, c)
+				}
+			}
+			var buf bytes.Buffer
+			if err := encodeWebP(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("%v alpha=%v: decode: %v", size, alpha, err)
+			}
+			got, ok := decoded.(*image.NRGBA)
+			if !ok || !bytes.Equal(got.Pix, img.Pix) {
+				t.Fatalf("%v alpha=%v: pixels differ after round trip", size, alpha)
+			}
+		}
+	}
+
+	// 单一颜色时所有通道只有一个符号
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webp.Decode(&buf); err != nil {
+		t.Fatalf("solid image: %v", err)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 100; x++ { // 左半边为红色
+		for y := 0; y < 100; y++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	// 插入带 GPS 标记和方向 6（需顺时针旋转 90°）的 EXIF
+	data := withExif(buf.Bytes(), 6)
+
+	res, err := Process(bytes.NewReader(data), ".JPG", testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := res.Original
+	if o.Ext != ".jpg" || o.Width != 32 || o.Height != 64 {
+		t.Fatalf("original: %s %dx%d", o.Ext, o.Width, o.Height)
+	}
+	if bytes.Contains(o.Data, []byte("Exif")) || bytes.Contains(o.Data, []byte("GPS")) {
+		t.Fatal("metadata was not stripped")
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(o.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 顺时针旋转后原来的左半边位于上半部分
+	if r, _, _, _ := decoded.At(16, 8).RGBA(); r < 0xc000 {
+		t.Fatalf("expected red at top after rotation, got %v", decoded.At(16, 8))
+	}
+
+	want := []struct {
+		name, ext string
+		w, h      int
+	}{{"thumbnail", ".jpg", 8, 16}, {"thumbnail", ".webp", 8, 16}, {"wide", ".jpg", 32, 64}, {"wide", ".webp", 32, 64}}
+	if len(res.Variants) != len(want) {
+		t.Fatalf("expected %d variants, got %d", len(want), len(res.Variants))
+	}
+	for i, v := range res.Variants {
+		if v.Name != want[i].name || v.Ext != want[i].ext || v.Width != want[i].w || v.Height != want[i].h {
+			t.Errorf("variant %d: %s%s %dx%d", i, v.Name, v.Ext, v.Width, v.Height)
+		}
+	}
+	if _, err := webp.Decode(bytes.NewReader(res.Variants[1].Data)); err != nil {
+		t.Fatalf("webp variant: %v", err)
+	}
+
+	buf.Reset()
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Process(bytes.NewReader(buf.Bytes()), ".jpg", testConfig); !errors.Is(err, ErrNotImage) {
+		t.Fatalf("expected ErrNotImage for png named .jpg, got %v", err)
+	}
+	if _, err := Process(bytes.NewReader([]byte("not an image")), ".png", testConfig); !errors.Is(err, ErrNotImage) {
+		t.Fatalf("expected ErrNotImage, got %v", err)
+	}
+	small := testConfig
+	small.MaxPixels = 100
+	if _, err := Process(bytes.NewReader(buf.Bytes()), ".png", small); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("expected ErrTooManyPixels, got %v", err)
+	}
+}
+
+// withExif 在 JPEG 的 SOI 之后插入只包含方向和 GPS IFD 指针的 APP1 段
+func withExif(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 2)
+	tiff = append(tiff, 0x01, 0x12, 0x00, 0x03, 0, 0, 0, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+	tiff = append(tiff, 0x88, 0x25, 0x00, 0x04, 0, 0, 0, 1, 0, 0, 0, 0) // GPSInfo
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS 31.2304N 121.4737E"...)
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xff, 0xe1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(seg)+2))
+	app1 = append(app1, seg...)
+	return append(append([]byte{0xff, 0xd8}, app1...), jpg[2:]...)
+}
@@ -0,0 +1,355 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"sort"
+)
+
+// 无损 WebP（VP8L）编码器。只使用减绿变换和整幅图片统一的梯度预测，不使用颜色缓存和反向引用，
+// 压缩率不如 libwebp，但不依赖 cgo，输出可被所有支持 WebP 的浏览器解码
+
+const (
+	webpMaxSize        = 16384 // VP8L 用 14 位保存宽高
+	webpPredictorBits  = 9     // 预测变换的分块为 512×512，整幅图片使用同一种预测模式
+	webpPredictorMode  = 12    // ClampAddSubtractFull(L, T, TL)，即梯度预测
+	webpGreenAlphabet  = 256 + 24
+	webpDistAlphabet   = 40
+	webpMaxCodeLength  = 15
+	webpMaxCLCodeLen   = 7
+	webpNumCodeLengths = 19
+)
+
+// webpCodeLengthOrder 码长的码长写入顺序
+var webpCodeLengthOrder = [webpNumCodeLengths]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP 将图片编码为无损 WebP
+func encodeWebP(w io.Writer, img *image.NRGBA) error {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= 0 || height <= 0 || width > webpMaxSize || height > webpMaxSize {
+		return errors.New("image size is out of range for webp")
+	}
+
+	// 减绿变换：红色和蓝色减去绿色
+	argb := make([][4]uint8, width*height) // 每个像素为 A、R、G、B
+	opaque := true
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := img.Pix[img.PixOffset(x, y):]
+			r, g, b, a := p[0], p[1], p[2], p[3]
+			argb[y*width+x] = [4]uint8{a, r - g, g, b - g}
+			opaque = opaque && a == 0xff
+		}
+	}
+
+	// 预测变换：保存与预测值的差；首个像素预测为不透明黑色，首行用左侧像素，首列用上方像素
+	residual := make([][4]uint8, len(argb))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var pred [4]uint8
+			switch {
+			case x == 0 && y == 0:
+				pred = [4]uint8{0xff, 0, 0, 0}
+			case y == 0:
+				pred = argb[i-1]
+			case x == 0:
+				pred = argb[i-width]
+			default:
+				l, t, tl := argb[i-1], argb[i-width], argb[i-width-1]
+				for c := 0; c < 4; c++ {
+					pred[c] = clampByte(int(l[c]) + int(t[c]) - int(tl[c]))
+				}
+			}
+			for c := 0; c < 4; c++ {
+				residual[i][c] = argb[i][c] - pred[c]
+			}
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8) // VP8L 签名
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3) // 版本
+
+	bw.write(1, 1) // 减绿变换
+	bw.write(2, 2)
+	bw.write(1, 1) // 预测变换，子图像每个像素的绿色通道为预测模式
+	bw.write(0, 2)
+	bw.write(webpPredictorBits-2, 3)
+	bw.write(0, 1) // 子图像不使用颜色缓存
+	writeSimpleCode(bw, webpPredictorMode)
+	for i := 0; i < 4; i++ { // 红、蓝、透明度和距离
+		writeSimpleCode(bw, 0)
+	}
+	// 子图像的所有符号均只有一种取值，像素不占用任何位
+	bw.write(0, 1) // 变换结束
+
+	bw.write(0, 1) // 不使用颜色缓存
+	bw.write(0, 1) // 整幅图片使用同一组前缀码
+	var hist [4][]uint32
+	hist[0] = make([]uint32, webpGreenAlphabet)
+	for c := 1; c < 4; c++ {
+		hist[c] = make([]uint32, 256)
+	}
+	for _, p := range residual {
+		hist[0][p[2]]++
+		hist[1][p[1]]++
+		hist[2][p[3]]++
+		hist[3][p[0]]++
+	}
+	var codes [4]*prefixCode
+	for c := range hist {
+		codes[c] = writePrefixCode(bw, hist[c])
+	}
+	writeSimpleCode(bw, 0) // 距离，未使用
+	for _, p := range residual {
+		codes[0].emit(bw, int(p[2]))
+		codes[1].emit(bw, int(p[1]))
+		codes[2].emit(bw, int(p[3]))
+		codes[3].emit(bw, int(p[0]))
+	}
+	data := bw.bytes()
+
+	// RIFF 容器，块长度为奇数时补一个字节
+	pad := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+len(data)+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if pad == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+func clampByte(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// bitWriter 按 VP8L 的约定从低位开始写入比特
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nacc
+	w.nacc += n
+	for w.nacc >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nacc -= 8
+	}
+}
+
+// bytes 补齐最后一个字节后返回全部内容
+func (w *bitWriter) bytes() []byte {
+	if w.nacc > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nacc = 0, 0
+	}
+	return w.buf
+}
+
+// prefixCode 前缀码；只有一个符号时不占用任何位
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16 // 已按写入顺序反转
+	single  bool
+}
+
+func (c *prefixCode) emit(w *bitWriter, symbol int) {
+	if !c.single {
+		w.write(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+	}
+}
+
+// writeSimpleCode 写入只有一个符号的简单前缀码
+func writeSimpleCode(w *bitWriter, symbol uint32) {
+	w.write(1, 1) // 简单前缀码
+	w.write(0, 1) // 一个符号
+	if symbol < 2 {
+		w.write(0, 1)
+		w.write(symbol, 1)
+	} else {
+		w.write(1, 1)
+		w.write(symbol, 8)
+	}
+}
+
+// writePrefixCode 按直方图写入前缀码并返回用于编码的码表
+func writePrefixCode(w *bitWriter, hist []uint32) *prefixCode {
+	var used []int
+	for s, n := range hist {
+		if n > 0 {
+			used = append(used, s)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		if len(used) == 1 {
+			writeSimpleCode(w, uint32(used[0]))
+			return &prefixCode{single: true}
+		}
+		w.write(1, 1) // 简单前缀码，两个符号各占 1 位
+		w.write(1, 1)
+		w.write(1, 1)
+		w.write(uint32(used[0]), 8)
+		w.write(uint32(used[1]), 8)
+		c := &prefixCode{lengths: make([]uint8, len(hist)), codes: make([]uint16, len(hist))}
+		c.lengths[used[0]], c.lengths[used[1]] = 1, 1
+		c.codes[used[1]] = 1
+		return c
+	}
+
+	code := newPrefixCode(hist, webpMaxCodeLength)
+	w.write(0, 1) // 普通前缀码
+	clHist := make([]uint32, webpNumCodeLengths)
+	for _, l := range code.lengths {
+		clHist[l]++
+	}
+	clCode := newPrefixCode(clHist, webpMaxCLCodeLen)
+	w.write(webpNumCodeLengths-4, 4)
+	for _, s := range webpCodeLengthOrder {
+		w.write(uint32(clCode.lengths[s]), 3)
+	}
+	w.write(0, 1) // 码长数量等于字母表大小
+	for _, l := range code.lengths {
+		clCode.emit(w, int(l))
+	}
+	return code
+}
+
+// newPrefixCode 由直方图生成码长不超过 limit 的规范哈夫曼码；只有一个符号时码长记为 1，编码时不占位
+func newPrefixCode(hist []uint32, limit int) *prefixCode {
+	c := &prefixCode{lengths: make([]uint8, len(hist)), codes: make([]uint16, len(hist))}
+	var used []int
+	for s, n := range hist {
+		if n > 0 {
+			used = append(used, s)
+		}
+	}
+	if len(used) == 1 {
+		c.lengths[used[0]] = 1
+		c.single = true
+		return c
+	}
+
+	counts := make([]uint32, len(hist))
+	copy(counts, hist)
+	for {
+		depths := huffmanDepths(counts, used)
+		maxDepth := 0
+		for _, d := range depths {
+			maxDepth = max(maxDepth, d)
+		}
+		if maxDepth <= limit {
+			for i, s := range used {
+				c.lengths[s] = uint8(depths[i])
+			}
+			break
+		}
+		// 码长过长时压平频率后重试，最终所有符号频率相同，码长为 log2(符号数)
+		for _, s := range used {
+			counts[s] = (counts[s] + 1) / 2
+		}
+	}
+
+	// 与解码器相同的规范码分配：码长相同的按符号顺序递增
+	var blCount [webpMaxCodeLength + 1]uint16
+	for _, l := range c.lengths {
+		blCount[l]++
+	}
+	blCount[0] = 0
+	var next [webpMaxCodeLength + 1]uint16
+	code := uint16(0)
+	for bits := 1; bits <= webpMaxCodeLength; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		next[bits] = code
+	}
+	for s, l := range c.lengths {
+		if l > 0 {
+			c.codes[s] = reverseBits(next[l], l)
+			next[l]++
+		}
+	}
+	return c
+}
+
+// huffmanDepths 返回 used 中各符号在哈夫曼树中的深度，使用两个队列合并已排序的频率
+func huffmanDepths(counts []uint32, used []int) []int {
+	n := len(used)
+	leaves := make([]int, n) // 按频率排序的 used 下标
+	for i := range leaves {
+		leaves[i] = i
+	}
+	sort.Slice(leaves, func(a, b int) bool {
+		ca, cb := counts[used[leaves[a]]], counts[used[leaves[b]]]
+		return ca < cb || ca == cb && leaves[a] < leaves[b]
+	})
+
+	weight := make([]uint64, 0, 2*n-1)
+	for _, i := range leaves {
+		weight = append(weight, uint64(counts[used[i]]))
+	}
+	parent := make([]int, 2*n-1)
+	li, ii := 0, n // 叶子队列和内部节点队列的队首
+	pop := func() int {
+		if li < n && (ii >= len(weight) || weight[li] <= weight[ii]) {
+			li++
+			return li - 1
+		}
+		ii++
+		return ii - 1
+	}
+	for len(weight) < 2*n-1 {
+		a, b := pop(), pop()
+		parent[a], parent[b] = len(weight), len(weight)
+		weight = append(weight, weight[a]+weight[b])
+	}
+
+	depth := make([]int, 2*n-1)
+	for i := 2*n - 3; i >= 0; i-- { // 根节点最后创建，深度为 0
+		depth[i] = depth[parent[i]] + 1
+	}
+	depths := make([]int, n)
+	for pos, i := range leaves {
+		depths[i] = depth[pos]
+	}
+	return depths
+}
+
+func reverseBits(code uint16, n uint8) uint16 {
+	var r uint16
+	for i := uint8(0); i < n; i++ {
+		r = r<<1 | code&1
+		code >>= 1
+	}
+	return r
+}
@@ -0,0 +1,119 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// closer 是需要在退出时释放的资源
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Manager 管理后台任务的启动和停止，以及资源的有序关闭
+type Manager struct {
+	logger  *zap.SugaredLogger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	closers []closer
+}
+
+// New 创建一个生命周期管理器
+func New(logger *zap.SugaredLogger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Go 启动一个后台任务，fn 应在 ctx 取消后尽快返回
+func (m *Manager) Go(name string, fn func(ctx context.Context) error) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				m.logger.Errorf("后台任务 %s 发生 panic: %v", name, r)
+			}
+		}()
+
+		m.logger.Infof("后台任务 %s 已启动", name)
+		if err := fn(m.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			m.logger.Errorf("后台任务 %s 异常退出: %v", name, err)
+			return
+		}
+		m.logger.Infof("后台任务 %s 已停止", name)
+	}()
+}
+
+// Every 启动一个按固定间隔执行的后台任务，单次失败只记录日志
+func (m *Manager) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	m.Go(name, func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					m.logger.Warnf("后台任务 %s 执行失败: %v", name, err)
+				}
+			}
+		}
+	})
+}
+
+// OnClose 注册退出时需要关闭的资源，按注册的相反顺序关闭
+func (m *Manager) OnClose(name string, fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, closer{name: name, fn: fn})
+}
+
+// Shutdown 停止所有后台任务并关闭资源
+// 后台任务在 ctx 到期前未退出时不再等待，资源仍会被关闭
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	var errs []error
+	select {
+	case <-done:
+		m.logger.Info("所有后台任务已停止")
+	case <-ctx.Done():
+		m.logger.Warn("等待后台任务停止超时")
+		errs = append(errs, fmt.Errorf("background workers did not stop in time: %w", ctx.Err()))
+	}
+
+	m.mu.Lock()
+	closers := m.closers
+	m.closers = nil
+	m.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		if err := c.fn(); err != nil {
+			m.logger.Errorf("关闭 %s 失败: %v", c.name, err)
+			errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+			continue
+		}
+		m.logger.Infof("%s 已关闭", c.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,64 @@
+package lifecycle
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestShutdownStopsWorkersAndClosesInReverseOrder(t *testing.T) {
+	m := New(zap.NewNop().Sugar())
+
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+
+	m.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		record("worker stopped")
+		return ctx.Err()
+	})
+	m.OnClose("db", func() error { record("db closed"); return nil })
+	m.OnClose("redis", func() error { record("redis closed"); return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	want := []string{"worker stopped", "redis closed", "db closed"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+}
+
+func TestShutdownTimesOutOnStuckWorker(t *testing.T) {
+	m := New(zap.NewNop().Sugar())
+	release := make(chan struct{})
+	defer close(release)
+
+	m.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	closed := false
+	m.OnClose("db", func() error { closed = true; return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !closed {
+		t.Error("expected resources to be closed even after timeout")
+	}
+}
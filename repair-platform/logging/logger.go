@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"os"
+
+	"repair-platform/config"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// level 是全局日志级别，运行时可通过管理接口调整
+var level = zap.NewAtomicLevel()
+
+// Level 返回全局日志级别
+func Level() zap.AtomicLevel {
+	return level
+}
+
+// New 按配置创建日志记录器，返回的关闭函数会刷新缓冲并关闭日志文件
+func New(cfg config.LogConfig) (*zap.Logger, func() error, error) {
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		writers []zapcore.WriteSyncer
+		rotator *lumberjack.Logger
+	)
+	if cfg.Output == config.LogOutputFile || cfg.Output == config.LogOutputBoth {
+		rotator = &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+			LocalTime:  true,
+		}
+		writers = append(writers, zapcore.AddSync(rotator))
+	}
+	if cfg.Output == config.LogOutputStdout || cfg.Output == config.LogOutputBoth {
+		writers = append(writers, zapcore.Lock(os.Stdout))
+	}
+
+	core := zapcore.NewCore(newEncoder(), zapcore.NewMultiWriteSyncer(writers...), level)
+	logger := zap.New(redactCore{core}, zap.AddCaller())
+
+	closeFn := func() error {
+		_ = logger.Sync()
+		if rotator != nil {
+			return rotator.Close()
+		}
+		return nil
+	}
+	return logger, closeFn, nil
+}
+
+// newEncoder 返回 JSON 格式的日志编码器
+func newEncoder() zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.MessageKey = "message"
+	encoderConfig.LevelKey = "level"
+	encoderConfig.TimeKey = "time"
+	encoderConfig.CallerKey = "caller"
+	return zapcore.NewJSONEncoder(encoderConfig)
+}
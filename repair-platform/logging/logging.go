@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 上下文中保存日志记录器和请求 ID 的键
+const (
+	loggerKey    = "logger"
+	requestIDKey = "request_id"
+)
+
+// RequestIDHeader 用于传递请求 ID 的 HTTP 头
+const RequestIDHeader = "X-Request-ID"
+
+// FromContext 返回当前请求的日志记录器，未经过 RequestLogger 中间件时返回全局记录器
+func FromContext(c *gin.Context) *zap.SugaredLogger {
+	if v, ok := c.Get(loggerKey); ok {
+		if logger, ok := v.(*zap.SugaredLogger); ok {
+			return logger
+		}
+	}
+	return zap.S()
+}
+
+// SetLogger 将日志记录器保存到请求上下文
+func SetLogger(c *gin.Context, logger *zap.SugaredLogger) {
+	c.Set(loggerKey, logger)
+}
+
+// With 为当前请求的日志记录器追加字段，后续 FromContext 返回的记录器都会带上这些字段
+func With(c *gin.Context, args ...interface{}) {
+	SetLogger(c, FromContext(c).With(args...))
+}
+
+// RequestID 返回当前请求的 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// SetRequestID 将请求 ID 保存到请求上下文
+func SetRequestID(c *gin.Context, id string) {
+	c.Set(requestIDKey, id)
+}
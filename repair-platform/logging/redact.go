@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue 替换敏感字段值的占位符
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys 中的字段名（不区分大小写）在写入日志前会被脱敏
+var sensitiveKeys = map[string]struct{}{
+	"password":          {},
+	"new_password":      {},
+	"code":              {},
+	"verification_code": {},
+	"invite_code":       {},
+	"token":             {},
+	"access_token":      {},
+	"secret":            {},
+	"authorization":     {},
+}
+
+// IsSensitiveKey 判断字段名是否需要脱敏
+func IsSensitiveKey(key string) bool {
+	_, ok := sensitiveKeys[strings.ToLower(key)]
+	return ok
+}
+
+// redactCore 包装 zapcore.Core，对敏感字段脱敏
+// 只能处理结构化字段（Infow 等），格式化到消息中的内容无法识别，调用方不应把敏感值写进消息
+type redactCore struct {
+	zapcore.Core
+}
+
+// With 实现 zapcore.Core
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+// Check 实现 zapcore.Core，确保写入时经过本包装
+func (c redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现 zapcore.Core
+func (c redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields 返回脱敏后的字段，没有敏感字段时原样返回
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if !IsSensitiveKey(f.Key) {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zap.String(f.Key, redactedValue)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
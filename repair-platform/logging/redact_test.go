@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactSensitiveFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(redactCore{core}).Sugar()
+
+	logger.With("token", "abc").Infow("注册", "username", "alice", "Password", "secret123", "code", "123456")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["username"] != "alice" {
+		t.Errorf("expected username to be kept, got %v", fields["username"])
+	}
+	for _, key := range []string{"token", "Password", "code"} {
+		if fields[key] != redactedValue {
+			t.Errorf("expected %s to be redacted, got %v", key, fields[key])
+		}
+	}
+}
@@ -1,95 +1,307 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"repair-platform/config"
 	"repair-platform/database"
+	"repair-platform/lifecycle"
+	"repair-platform/logging"
+	"repair-platform/metrics"
+	"repair-platform/migrations"
 	"repair-platform/routes"
+	"repair-platform/scheduler"
+	"repair-platform/search"
 	"repair-platform/service"
+	"repair-platform/storage"
+	"repair-platform/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
 )
 
 var sugar *zap.SugaredLogger
 
+// defaultConfigPath 未指定 -config 时尝试加载的配置文件
+const defaultConfigPath = "config.yaml"
+
 func main() {
+	os.Exit(run())
+}
+
+// run 完成初始化并运行服务，返回进程退出码；所有清理工作都通过 defer 或生命周期管理器完成
+func run() int {
+	configPath := flag.String("config", os.Getenv("REPAIR_CONFIG"), "配置文件路径 (.yaml/.yml/.toml)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), commandUsage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// 加载配置，日志输出方式由配置决定，因此失败时只能输出到标准错误
+	path := resolveConfigPath(*configPath)
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
 	// 初始化日志
 	gin.SetMode(gin.ReleaseMode)
-	initLogger()
-	defer sugar.Sync()
+	closeLogger, err := initLogger(cfg.Log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化日志失败: %v\n", err)
+		return 1
+	}
+	defer closeLogger()
 
 	sugar.Info("服务初始化开始")
+	if path != "" {
+		sugar.Infof("已加载配置文件: %s", path)
+	}
+
+	// 执行子命令
+	if flag.NArg() > 0 {
+		if err := runCommand(cfg, flag.Args()); err != nil {
+			sugar.Errorf("命令执行失败: %v", err)
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	// 生命周期管理器负责后台任务和资源的有序关闭
+	lc := lifecycle.New(sugar)
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
+		defer cancel()
+		if err := lc.Shutdown(ctx); err != nil {
+			sugar.Errorf("关闭后台任务和资源时出错: %v", err)
+		}
+	}
+	defer shutdown()
+
+	// 初始化链路追踪，最先注册因此最后关闭，保证其他资源关闭过程中的 span 也能导出
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		sugar.Errorf("初始化链路追踪失败: %v", err)
+		return 1
+	}
+	lc.OnClose("链路追踪", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	if cfg.Tracing.Enabled {
+		sugar.Infof("链路追踪已启用, 导出方式: %s", cfg.Tracing.Exporter)
+	}
 
 	// 初始化 Gin 引擎
 	sugar.Info("初始化 Gin 引擎")
-	r := gin.Default()
+	r := gin.New()
 
 	// 配置 CORS 中间件
-	setupCORS(r)
+	setupCORS(r, cfg.CORS)
 
 	// 初始化数据库
 	sugar.Info("初始化数据库连接")
-	db, err := database.InitDB()
+	db, err := database.InitDB(cfg.Database)
 	if err != nil {
-		sugar.Fatalf("数据库连接失败: %v", err)
+		sugar.Errorf("数据库连接失败: %v", err)
+		return 1
 	}
-	defer func() {
+	lc.OnClose("数据库连接", func() error {
 		database.CloseDB(db)
-		sugar.Info("数据库连接已关闭")
-	}()
+		return nil
+	})
+
+	// 拒绝在未迁移的数据库上运行
+	if err := migrations.CheckUpToDate(db); err != nil {
+		sugar.Errorf("数据库结构检查失败: %v", err)
+		return 1
+	}
+
+	if cfg.Tracing.Enabled {
+		if err := db.Use(tracing.GormPlugin{}); err != nil {
+			sugar.Errorf("注册数据库链路追踪失败: %v", err)
+			return 1
+		}
+	}
+
+	// 注册数据库和业务指标
+	if cfg.Metrics.Enabled {
+		if err := db.Use(metrics.GormPlugin{}); err != nil {
+			sugar.Errorf("注册数据库指标失败: %v", err)
+			return 1
+		}
+		if err := metrics.RegisterBusinessCollector(db); err != nil {
+			sugar.Errorf("注册业务指标失败: %v", err)
+			return 1
+		}
+	}
 
-	// 初始化 Email 服务
+	// 配置了地址时才连接 Redis
+	if cfg.Redis.Addr != "" {
+		sugar.Info("初始化 Redis 连接")
+		if err := database.InitRedis(cfg.Redis); err != nil {
+			sugar.Errorf("Redis 连接失败: %v", err)
+			return 1
+		}
+		lc.OnClose("Redis 连接", database.CloseRedis)
+		if cfg.Tracing.Enabled {
+			if err := redisotel.InstrumentTracing(database.GetRedisClient()); err != nil {
+				sugar.Errorf("注册 Redis 链路追踪失败: %v", err)
+				return 1
+			}
+		}
+	}
+
+	// 初始化上传文件存储，本地和内存后端的下载地址使用 JWT 密钥签名
+	sugar.Infof("初始化上传文件存储, 后端: %s", cfg.Storage.Backend)
+	store, err := storage.New(cfg.Storage, cfg.Upload.Dir, storage.NewSigner(cfg.Auth.JWTSecret))
+	if err != nil {
+		sugar.Errorf("初始化上传文件存储失败: %v", err)
+		return 1
+	}
+
+	// 初始化 Email 服务，邮件通过队列异步发送
 	sugar.Info("初始化 Email 服务")
-	emailService := service.NewEmailService(sugar)
+	mailQueue := service.NewMailQueue(service.NewEmailService(cfg.SMTP, sugar), cfg.SMTP.QueueSize, cfg.SMTP.MaxRetries, sugar)
+
+	// 构建全文检索索引
+	indexed, err := search.Default.Rebuild(context.Background(), db)
+	if err != nil {
+		sugar.Errorf("构建检索索引失败: %v", err)
+		return 1
+	}
+	sugar.Infof("检索索引已构建, 文档数: %d", indexed)
+
+	// 启动后台任务
+	if err := startWorkers(lc, cfg, db, mailQueue, store); err != nil {
+		sugar.Errorf("启动后台任务失败: %v", err)
+		return 1
+	}
 
 	// 配置路由
 	sugar.Info("配置路由和中间件")
-	routes.SetupRoutes(r, db, emailService)
+	routes.SetupRoutes(r, db, mailQueue, store, cfg)
 
-	// 启动服务器
-	startServer(r)
+	// 启动服务器，收到退出信号后优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := startServer(ctx, r, cfg.Server); err != nil {
+		sugar.Errorf("服务器运行失败: %v", err)
+		return 1
+	}
+	return 0
 }
 
-// initLogger 初始化 zap 日志记录器
-func initLogger() {
-	writeSyncer := getLogWriter() // 获取日志写入器
-	encoder := getEncoder()       // 获取编码器
-	core := zapcore.NewCore(encoder, writeSyncer, zapcore.InfoLevel)
-	logger := zap.New(core, zap.AddCaller()) // 创建 logger
-	sugar = logger.Sugar()
+// startWorkers 注册并启动后台任务
+func startWorkers(lc *lifecycle.Manager, cfg *config.Config, db *gorm.DB, mailQueue *service.MailQueue, store storage.Storage) error {
+	lc.Go("邮件队列", mailQueue.Run)
+
+	slaChecker := service.NewSLAChecker(db, mailQueue, cfg.Workers.SLAPendingTimeout.Std(), cfg.Workers.SLACheckInterval.Std(), sugar)
+	lc.Every("报修 SLA 检查", cfg.Workers.SLACheckInterval.Std(), slaChecker.Check)
+
+	// 每个实例各自维护内存中的检索索引，定期重建以同步其他实例的修改
+	lc.Every("检索索引重建", cfg.Workers.SearchReindexInterval.Std(), func(ctx context.Context) error {
+		_, err := search.Default.Rebuild(ctx, db)
+		return err
+	})
+
+	// 配置了 Redis 时用 Redis 做任务锁，否则使用数据库
+	var locker scheduler.Locker = scheduler.NewDBLocker(db)
+	if client := database.GetRedisClient(); client != nil {
+		locker = scheduler.NewRedisLocker(client)
+	}
+	sched := scheduler.New(locker, cfg.Workers.LockTTL.Std(), sugar)
+
+	jobs := []scheduler.Job{
+		{
+			Name: "purge_expired_tokens",
+			Spec: cfg.Workers.TokenPurgeSchedule,
+			Run: func(ctx context.Context) error {
+				return service.PurgeExpiredTokens(ctx, db)
+			},
+		},
+		{
+			Name: "sweep_orphan_uploads",
+			Spec: cfg.Workers.OrphanSweepSchedule,
+			Run: func(ctx context.Context) error {
+				removed, err := service.SweepOrphanUploads(ctx, db, store, cfg.Workers.OrphanGracePeriod.Std())
+				sugar.Infof("清理孤立上传文件 %d 个", removed)
+				return err
+			},
+		},
+		{
+			Name: "purge_soft_deleted",
+			Spec: cfg.Workers.SoftDeletePurgeSchedule,
+			Run: func(ctx context.Context) error {
+				purged, err := service.PurgeSoftDeleted(ctx, db, storage.NewLocal(cfg.Upload.MarkdownDir, nil), cfg.Workers.SoftDeleteRetention.Std())
+				sugar.Infof("彻底删除软删除记录 %d 条", purged)
+				return err
+			},
+		},
+		{
+			Name: "publish_scheduled_posts",
+			Spec: cfg.Workers.PostPublishSchedule,
+			Run: func(ctx context.Context) error {
+				posts, err := service.PublishDuePosts(ctx, db, time.Now())
+				for i := range posts {
+					search.Default.Put(search.PostDocument(&posts[i]))
+					sugar.Infof("定时发布文章: %s (ID %d)", posts[i].Title, posts[i].ID)
+				}
+				return err
+			},
+		},
+	}
+	for _, job := range jobs {
+		if err := sched.Add(job); err != nil {
+			return err
+		}
+	}
+	lc.Go("定时任务调度", sched.Run)
+	return nil
 }
 
-// getEncoder 返回一个 JSON 格式的日志编码器
-func getEncoder() zapcore.Encoder {
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.MessageKey = "message"
-	encoderConfig.LevelKey = "level"
-	encoderConfig.TimeKey = "time"
-	encoderConfig.CallerKey = "caller"
-	return zapcore.NewJSONEncoder(encoderConfig)
+// resolveConfigPath 未指定配置文件时，若工作目录下存在 config.yaml 则使用它
+func resolveConfigPath(path string) string {
+	if path == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			return defaultConfigPath
+		}
+	}
+	return path
 }
 
-// getLogWriter 返回日志写入器，将日志保存到文件
-func getLogWriter() zapcore.WriteSyncer {
-	file, err := os.OpenFile("server.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// initLogger 按配置初始化 zap 日志记录器，返回的函数在退出时刷新并关闭日志文件
+func initLogger(cfg config.LogConfig) (func() error, error) {
+	logger, closeFn, err := logging.New(cfg)
 	if err != nil {
-		panic("无法创建或打开日志文件")
+		return nil, err
 	}
-	return zapcore.AddSync(file)
+	zap.ReplaceGlobals(logger) // 让 zap.L()/zap.S() 使用同一个 logger
+	sugar = logger.Sugar()
+	return closeFn, nil
 }
 
 // setupCORS 配置 CORS 中间件
-func setupCORS(r *gin.Engine) {
+func setupCORS(r *gin.Engine, cfg config.CORSConfig) {
 	sugar.Info("配置 CORS 中间件")
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:11451"}, // 指定前端的地址
+		AllowOrigins:     cfg.AllowOrigins, // 指定前端的地址
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		AllowCredentials: true, // 允许携带凭证（如 Cookies）
@@ -97,23 +309,39 @@ func setupCORS(r *gin.Engine) {
 	}))
 }
 
-// startServer 启动服务器
-func startServer(r *gin.Engine) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+// startServer 启动服务器并阻塞，ctx 取消后停止接受新连接并等待进行中的请求完成
+func startServer(ctx context.Context, r *gin.Engine, cfg config.ServerConfig) error {
+	port := strconv.Itoa(cfg.Port)
 
 	sugar.Infof("服务器即将启动，监听端口: %s", port)
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      r,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  30 * time.Second,
+		ReadTimeout:  cfg.ReadTimeout.Std(),
+		WriteTimeout: cfg.WriteTimeout.Std(),
+		IdleTimeout:  cfg.IdleTimeout.Std(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
 	}
 
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		sugar.Fatalf("服务器启动失败: %v", err)
+	sugar.Infof("收到退出信号，等待进行中的请求完成（最长 %s）", cfg.ShutdownTimeout.Std())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Std())
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
 	}
+	sugar.Info("HTTP 服务已停止")
+	return nil
 }
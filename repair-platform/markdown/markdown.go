@@ -0,0 +1,299 @@
+// Package markdown 将 Markdown 渲染为经过过滤的 HTML，同时生成目录和阅读时间
+package markdown
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// 阅读速度，中日韩文字按字数、其他文字按单词数计算
+const (
+	cjkCharsPerMinute = 300
+	wordsPerMinute    = 200
+)
+
+// DefaultCacheSize 默认缓存的渲染结果数量
+const DefaultCacheSize = 256
+
+// TOCEntry 目录中的一个标题，按文中出现的顺序排列
+type TOCEntry struct {
+	Level int    `json:"level"` // 1-6
+	ID    string `json:"id"`    // 标题元素的 id，可用于锚点
+	Text  string `json:"text"`
+}
+
+// Result 渲染结果，HTML 已经过过滤可以直接插入页面
+type Result struct {
+	HTML        string     `json:"html"`
+	TOC         []TOCEntry `json:"toc"`
+	WordCount   int        `json:"word_count"`
+	ReadingTime int        `json:"reading_time"` // 预计阅读分钟数
+}
+
+// Renderer 渲染 Markdown 并按内容哈希缓存结果，可并发使用
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // 最近使用的在前
+}
+
+// cacheEntry LRU 缓存中的一项
+type cacheEntry struct {
+	key    string
+	result *Result
+}
+
+// Default 服务使用的渲染器
+var Default = NewRenderer(DefaultCacheSize)
+
+// NewRenderer 创建渲染器，cacheSize <= 0 时不缓存
+func NewRenderer(cacheSize int) *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM, // 表格、删除线、自动链接和任务列表
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle("github"),
+				highlighting.WithFormatOptions(chromahtml.TabWidth(4)),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		// 允许原始 HTML，输出统一由 bluemonday 过滤
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	return &Renderer{
+		md:       md,
+		policy:   newPolicy(),
+		capacity: cacheSize,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// newPolicy 在 UGC 策略的基础上允许代码高亮、任务列表和脚注需要的属性
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// 标题 id 可能包含中文
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}:_.\-]+$`)).Globally()
+	// 任务列表
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	// 脚注
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^footnote(s|-ref|-backref)$`)).OnElements("a", "div")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|endnotes|backlink)$`)).OnElements("a", "div", "sup")
+	// 代码高亮使用内联样式，只允许颜色和字体相关的属性
+	p.AllowAttrs("tabindex").Matching(regexp.MustCompile(`^0$`)).OnElements("pre")
+	p.AllowStyles("color", "background-color").OnElements("pre", "span")
+	p.AllowStyles("font-weight", "font-style", "text-decoration").OnElements("span")
+	p.AllowStyles("display").MatchingEnum("flex").OnElements("span")
+	return p
+}
+
+// Render 渲染 Markdown，相同内容直接返回缓存的结果；返回值不应被修改
+func (r *Renderer) Render(source []byte) (*Result, error) {
+	sum := sha256.Sum256(source)
+	key := hex.EncodeToString(sum[:])
+	if result, ok := r.get(key); ok {
+		return result, nil
+	}
+
+	result, err := r.render(source)
+	if err != nil {
+		return nil, err
+	}
+	r.put(key, result)
+	return result, nil
+}
+
+// render 执行实际的渲染
+func (r *Renderer) render(source []byte) (*Result, error) {
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := r.md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	words := countWords(doc, source)
+	return &Result{
+		HTML:        r.policy.Sanitize(buf.String()),
+		TOC:         tableOfContents(doc, source),
+		WordCount:   words.total(),
+		ReadingTime: words.minutes(),
+	}, nil
+}
+
+// get 从缓存中取出结果并标记为最近使用
+func (r *Renderer) get(key string) (*Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	r.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).result, true
+}
+
+// put 写入缓存，超出容量时淘汰最久未使用的结果
+func (r *Renderer) put(key string, result *Result) {
+	if r.capacity <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.entries[key]; ok {
+		r.order.MoveToFront(el)
+		return
+	}
+	r.entries[key] = r.order.PushFront(&cacheEntry{key: key, result: result})
+	for r.order.Len() > r.capacity {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// tableOfContents 按出现顺序收集所有标题
+func tableOfContents(doc ast.Node, source []byte) []TOCEntry {
+	toc := []TOCEntry{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		toc = append(toc, TOCEntry{
+			Level: heading.Level,
+			ID:    string(idBytes),
+			Text:  string(heading.Text(source)),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// wordCount 分别统计中日韩文字和其他单词
+type wordCount struct {
+	cjk   int
+	words int
+}
+
+func (w wordCount) total() int {
+	return w.cjk + w.words
+}
+
+// minutes 预计阅读分钟数，有内容时至少为 1
+func (w wordCount) minutes() int {
+	if w.total() == 0 {
+		return 0
+	}
+	minutes := float64(w.cjk)/cjkCharsPerMinute + float64(w.words)/wordsPerMinute
+	if minutes < 1 {
+		return 1
+	}
+	return int(minutes + 0.5)
+}
+
+// countWords 统计正文文字，不包括代码块
+func countWords(doc ast.Node, source []byte) wordCount {
+	var count wordCount
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			count.add(n.Segment.Value(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return count
+}
+
+// add 统计一段文字，中日韩文字每个字算一个词，其他文字按空白和标点分词
+func (w *wordCount) add(s []byte) {
+	inWord := false
+	for _, r := range string(s) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			w.cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				w.words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+}
+
+// headingIDs 为标题生成唯一的 id，保留中文等非 ASCII 字符
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() parser.IDs {
+	return &headingIDs{used: map[string]bool{}}
+}
+
+// Generate 实现 parser.IDs
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(string(util.TrimLeftSpace(util.TrimRightSpace(value)))) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; s.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	s.used[id] = true
+	return []byte(id)
+}
+
+// Put 实现 parser.IDs，记录文档中手动指定的 id
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = true
+}
@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizesHTML(t *testing.T) {
+	source := "Hello\n\n<script>alert(1)</script>\n\n<a href=\"javascript:alert(1)\" onclick=\"x()\">link</a>\n\n<img src=x onerror=alert(1)>\n"
+	result, err := NewRenderer(0).Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, bad := range []string{"<script", "javascript:", "onclick", "onerror"} {
+		if strings.Contains(result.HTML, bad) {
+			t.Errorf("expected %q to be removed, got:\n%s", bad, result.HTML)
+		}
+	}
+}
+
+func TestRenderGFMAndTOC(t *testing.T) {
+	source := "# 标题\n\n## Intro\n\n## Intro\n\n- [x] done\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\ntext[^1]\n\n```go\nfunc main() {}\n```\n\n[^1]: note\n"
+	result, err := NewRenderer(0).Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{
+		`<h1 id="标题">`, `<h2 id="intro-1">`, `<table>`, `type="checkbox"`,
+		`class="footnote-ref"`, `<span style="color: #000; font-weight: bold">func</span>`,
+	} {
+		if !strings.Contains(result.HTML, want) {
+			t.Errorf("expected %q in output:\n%s", want, result.HTML)
+		}
+	}
+
+	want := []TOCEntry{{1, "标题", "标题"}, {2, "intro", "Intro"}, {2, "intro-1", "Intro"}}
+	if len(result.TOC) != len(want) {
+		t.Fatalf("unexpected toc %+v", result.TOC)
+	}
+	for i := range want {
+		if result.TOC[i] != want[i] {
+			t.Errorf("toc %d: expected %+v, got %+v", i, want[i], result.TOC[i])
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	source := strings.Repeat("字", 600) + "\n\n" + strings.Repeat("word ", 200) + "\n\n```\n" + strings.Repeat("code ", 1000) + "\n```\n"
+	result, err := NewRenderer(0).Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if result.WordCount != 800 || result.ReadingTime != 3 {
+		t.Errorf("expected 800 words and 3 minutes, got %d and %d", result.WordCount, result.ReadingTime)
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	r := NewRenderer(1)
+	first, _ := r.Render([]byte("a"))
+	again, _ := r.Render([]byte("a"))
+	if first != again {
+		t.Error("expected cached result for identical content")
+	}
+	_, _ = r.Render([]byte("b"))
+	if evicted, _ := r.Render([]byte("a")); evicted == first {
+		t.Error("expected least recently used entry to be evicted")
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"repair-platform/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+var openRepairsDesc = prometheus.NewDesc(
+	"repair_requests_open",
+	"未完成的报修请求数，按状态和优先级分类",
+	[]string{"status", "priority"}, nil,
+)
+
+// repairCollector 在每次抓取时从数据库统计未完成的报修请求
+type repairCollector struct {
+	db *gorm.DB
+}
+
+// RegisterBusinessCollector 注册业务指标，只应调用一次
+func RegisterBusinessCollector(db *gorm.DB) error {
+	return Registry.Register(&repairCollector{db: db})
+}
+
+// Describe 实现 prometheus.Collector
+func (c *repairCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openRepairsDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *repairCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var rows []struct {
+		Status   string
+		Priority string
+		Count    int64
+	}
+	err := c.db.WithContext(ctx).Model(&models.RepairRequest{}).
+		Select("status, priority, count(*) AS count").
+		Where("status <> ?", models.StatusCompleted).
+		Group("status, priority").
+		Scan(&rows).Error
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(openRepairsDesc, err)
+		return
+	}
+
+	// 未设置状态的请求按待处理统计，先合并再输出避免重复的标签组合
+	counts := make(map[[2]string]int64)
+	for _, row := range rows {
+		status := row.Status
+		if status == "" {
+			status = models.StatusPending
+		}
+		priority := row.Priority
+		if priority == "" {
+			priority = "unset"
+		}
+		counts[[2]string{status, priority}] += row.Count
+	}
+	for labels, count := range counts {
+		ch <- prometheus.MustNewConstMetric(openRepairsDesc, prometheus.GaugeValue, float64(count), labels[0], labels[1])
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"repair-platform/apperror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry 是服务使用的 Prometheus 注册表，不使用全局默认注册表以免引入无关指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP 请求总数",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP 请求处理耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "数据库操作耗时",
+		Buckets: []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	dbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "数据库操作失败次数",
+	}, []string{"operation", "table"})
+
+	mailSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mail_send_total",
+		Help: "邮件发送次数，按结果分类",
+	}, []string{"result"})
+
+	uploadSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upload_size_bytes",
+		Help:    "上传文件大小",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 8), // 1KB ~ 16MB
+	}, []string{"kind"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbQueryDuration,
+		dbQueryErrors,
+		mailSent,
+		uploadSize,
+	)
+}
+
+// Middleware 记录每个路由的请求数和耗时，路由使用注册时的模板（如 /api/feedback/:id）以控制标签基数
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler 返回 /metrics 处理函数，token 非空时要求 Authorization: Bearer <token>
+func Handler(token string) gin.HandlerFunc {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	return func(c *gin.Context) {
+		if token != "" && c.GetHeader("Authorization") != "Bearer "+token {
+			apperror.Abort(c, apperror.ErrMetricsTokenInvalid)
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// ObserveMail 记录一次邮件发送结果
+func ObserveMail(err error) {
+	if err != nil {
+		mailSent.WithLabelValues("failure").Inc()
+		return
+	}
+	mailSent.WithLabelValues("success").Inc()
+}
+
+// ObserveUpload 记录一次上传的文件大小，kind 如 repair、markdown、image
+func ObserveUpload(kind string, size int64) {
+	uploadSize.WithLabelValues(kind).Observe(float64(size))
+}
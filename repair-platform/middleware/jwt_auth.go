@@ -1,26 +1,26 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
+	"repair-platform/apperror"
+	"repair-platform/i18n"
+	"repair-platform/logging"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// 定义 JWT 密钥
-var jwtSecret = []byte("JNU_technicians_club")
-
-// JWTAuthMiddleware 返回一个 JWT 认证的中间件
-func JWTAuthMiddleware() gin.HandlerFunc {
+// JWTAuthMiddleware 返回一个 JWT 认证的中间件，secret 为签名密钥
+func JWTAuthMiddleware(secret string) gin.HandlerFunc {
+	jwtSecret := []byte(secret)
 	return func(c *gin.Context) {
 		// 从请求头中获取 Authorization 字段
 		tokenString := c.GetHeader("Authorization")
 
 		// 检查 Authorization 字段是否存在，并且是否以 "Bearer " 开头
 		if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token not provided or invalid"})
-			c.Abort()
+			apperror.Abort(c, apperror.ErrAuthTokenMissing)
 			return
 		}
 
@@ -34,8 +34,7 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		// 检查 token 是否有效
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			apperror.Abort(c, apperror.ErrAuthTokenInvalid)
 			return
 		}
 
@@ -44,13 +43,19 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			// 将用户信息存储到上下文中，便于控制器中使用
 			if username, exists := claims["username"]; exists {
 				c.Set("username", username)
+				logging.With(c, "user", username)
 			}
 			if role, exists := claims["role"]; exists {
 				c.Set("role", role)
 			}
+			// 用户设置过语言偏好时优先于 Accept-Language
+			if locale, ok := claims["locale"].(string); ok {
+				if l, ok := i18n.Parse(locale); ok {
+					i18n.SetLocale(c, l)
+				}
+			}
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid JWT claims"})
-			c.Abort()
+			apperror.Abort(c, apperror.ErrAuthTokenInvalid)
 			return
 		}
 
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"repair-platform/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale 根据 Accept-Language 选择响应语言，登录用户的语言偏好由 JWTAuthMiddleware 覆盖
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		i18n.SetLocale(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"repair-platform/apperror"
+	"repair-platform/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 捕获处理函数中的 panic，记录堆栈并返回统一格式的 500 错误
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			logger := logging.FromContext(c)
+
+			// 客户端断开连接时无法再写响应，只记录日志
+			if isBrokenPipe(r) {
+				logger.Warnw("客户端连接已断开", "error", r)
+				c.Abort()
+				return
+			}
+
+			logger.Errorw("请求处理发生 panic", "panic", r, "stack", string(debug.Stack()))
+			apperror.Abort(c, apperror.ErrInternal.Wrap(fmt.Errorf("panic: %v", r)))
+		}()
+		c.Next()
+	}
+}
+
+// isBrokenPipe 判断 panic 是否由客户端断开连接引起
+func isBrokenPipe(r interface{}) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	msg := strings.ToLower(syscallErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"repair-platform/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// maxRequestIDLength 客户端传入的请求 ID 的最大长度
+const maxRequestIDLength = 64
+
+// RequestLogger 为每个请求生成或沿用 X-Request-ID，并在上下文中放入带请求 ID 和路由的日志记录器
+func RequestLogger(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		logging.SetRequestID(c, requestID)
+		c.Header(logging.RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		fields := []interface{}{
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"route", route,
+		}
+		// 启用链路追踪时附带 trace_id，便于从日志跳转到对应的链路
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			fields = append(fields, "trace_id", sc.TraceID().String())
+		}
+		logging.SetLogger(c, base.Sugar().With(fields...))
+
+		start := time.Now()
+		c.Next()
+
+		logging.FromContext(c).Infow("请求处理完成",
+			"status", c.Writer.Status(),
+			"latency", time.Since(start).String(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// isValidRequestID 只接受长度适中的可打印 ASCII 字符，避免日志注入
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成 16 字节的随机十六进制请求 ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
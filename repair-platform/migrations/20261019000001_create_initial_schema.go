@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下结构体是迁移执行时的表结构快照，之后修改 models 不会影响本迁移
+
+type initialUser struct {
+	ID         uint   `gorm:"primaryKey"`
+	Username   string `gorm:"unique;not null"`
+	Password   string `gorm:"not null"`
+	Email      string `gorm:"unique;not null"`
+	Role       string `gorm:"not null"`
+	IsVerified bool   `gorm:"default:false"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (initialUser) TableName() string { return "user" }
+
+type initialRepairRequest struct {
+	gorm.Model
+	UserID       uint
+	TechnicianID uint
+	Description  string
+	Status       string
+	Location     string
+	Priority     string
+	ImageURL     string
+	CompletedAt  *time.Time
+}
+
+func (initialRepairRequest) TableName() string { return "repair_request" }
+
+type initialFeedback struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+	UserID    uint
+	RepairID  uint
+	Rating    int
+	Comments  string `gorm:"type:varchar(255)"`
+}
+
+func (initialFeedback) TableName() string { return "feedback" }
+
+type initialPasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Token     string    `gorm:"not null;unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (initialPasswordResetToken) TableName() string { return "password_reset_tokens" }
+
+// 初始结构与此前 AutoMigrate 生成的表一致，已有数据库执行时只会补齐缺失部分
+func init() {
+	register(Migration{
+		Version: "20261019000001",
+		Name:    "create_initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&initialUser{}, &initialRepairRequest{}, &initialFeedback{}, &initialPasswordResetToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&initialPasswordResetToken{}, &initialFeedback{}, &initialRepairRequest{}, &initialUser{})
+		},
+	})
+}
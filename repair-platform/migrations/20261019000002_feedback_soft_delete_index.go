@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// feedbackSoftDelete 是 Feedback 改用 gorm.DeletedAt 之后的表结构快照
+type feedbackSoftDelete struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	UserID    uint
+	RepairID  uint `gorm:"index"`
+	Rating    int
+	Comments  string `gorm:"type:varchar(255)"`
+}
+
+func (feedbackSoftDelete) TableName() string { return "feedback" }
+
+// 旧的 sql:"index" 标签在 GORM v2 中不生效，补建软删除和维修请求的索引
+func init() {
+	register(Migration{
+		Version: "20261019000002",
+		Name:    "feedback_soft_delete_index",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"DeletedAt", "RepairID"} {
+				if m.HasIndex(&feedbackSoftDelete{}, field) {
+					continue
+				}
+				if err := m.CreateIndex(&feedbackSoftDelete{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"RepairID", "DeletedAt"} {
+				if !m.HasIndex(&feedbackSoftDelete{}, field) {
+					continue
+				}
+				if err := m.DropIndex(&feedbackSoftDelete{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type schedulerLock struct {
+	Name        string    `gorm:"primaryKey;size:100"`
+	Holder      string    `gorm:"size:255;not null"`
+	LockedUntil time.Time `gorm:"not null"`
+}
+
+func (schedulerLock) TableName() string { return "scheduler_locks" }
+
+// 定时任务的数据库锁表
+func init() {
+	register(Migration{
+		Version: "20261019000003",
+		Name:    "create_scheduler_locks",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&schedulerLock{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&schedulerLock{})
+		},
+	})
+}
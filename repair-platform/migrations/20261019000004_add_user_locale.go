@@ -0,0 +1,32 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// userLocale 是 User 新增语言偏好字段后的部分表结构快照
+type userLocale struct {
+	Locale string `gorm:"size:10"`
+}
+
+func (userLocale) TableName() string { return "user" }
+
+// 用户语言偏好，用于 API 提示和邮件模板
+func init() {
+	register(Migration{
+		Version: "20261019000004",
+		Name:    "add_user_locale",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasColumn(&userLocale{}, "Locale") {
+				return nil
+			}
+			return m.AddColumn(&userLocale{}, "Locale")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&userLocale{}, "Locale") {
+				return nil
+			}
+			return m.DropColumn(&userLocale{}, "Locale")
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// post 是创建文章表时的表结构快照
+type post struct {
+	ID          uint   `gorm:"primaryKey"`
+	Title       string `gorm:"size:200;not null"`
+	Slug        string `gorm:"size:200;not null;uniqueIndex"`
+	AuthorID    uint   `gorm:"index"`
+	Author      string `gorm:"size:100"`
+	Folder      string `gorm:"size:100;index"`
+	Tags        string `gorm:"type:text"`
+	Summary     string `gorm:"size:500"`
+	Status      string `gorm:"size:20;not null;index"`
+	Body        string `gorm:"type:text"`
+	SourcePath  string `gorm:"size:500;index"`
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (post) TableName() string { return "post" }
+
+// 博客文章表，取代直接读取 uploads 下的 Markdown 文件
+func init() {
+	register(Migration{
+		Version: "20261019000005",
+		Name:    "create_posts",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&post{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&post{})
+		},
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// postCover 是 Post 新增封面字段后的部分表结构快照
+type postCover struct {
+	Cover string `gorm:"size:500"`
+}
+
+func (postCover) TableName() string { return "post" }
+
+// 文章封面图片，来自 front matter 的 cover 字段
+func init() {
+	register(Migration{
+		Version: "20261019000006",
+		Name:    "add_post_cover",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasColumn(&postCover{}, "Cover") {
+				return nil
+			}
+			return m.AddColumn(&postCover{}, "Cover")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&postCover{}, "Cover") {
+				return nil
+			}
+			return m.DropColumn(&postCover{}, "Cover")
+		},
+	})
+}
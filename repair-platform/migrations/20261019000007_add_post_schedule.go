@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// postSchedule 是 Post 新增定时发布和预览令牌字段后的部分表结构快照
+type postSchedule struct {
+	PublishAt    *time.Time `gorm:"index"`
+	PreviewToken string     `gorm:"size:64;index"`
+}
+
+func (postSchedule) TableName() string { return "post" }
+
+// 文章定时发布时间和预览链接令牌
+func init() {
+	fields := []string{"PublishAt", "PreviewToken"}
+	register(Migration{
+		Version: "20261019000007",
+		Name:    "add_post_schedule",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range fields {
+				if !m.HasColumn(&postSchedule{}, field) {
+					if err := m.AddColumn(&postSchedule{}, field); err != nil {
+						return err
+					}
+				}
+				if !m.HasIndex(&postSchedule{}, field) {
+					if err := m.CreateIndex(&postSchedule{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range fields {
+				if m.HasIndex(&postSchedule{}, field) {
+					if err := m.DropIndex(&postSchedule{}, field); err != nil {
+						return err
+					}
+				}
+				if m.HasColumn(&postSchedule{}, field) {
+					if err := m.DropColumn(&postSchedule{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// postRevision 是创建文章版本表时的表结构快照
+type postRevision struct {
+	ID         uint   `gorm:"primaryKey"`
+	PostID     uint   `gorm:"not null;uniqueIndex:idx_post_revision_number"`
+	Number     int    `gorm:"not null;uniqueIndex:idx_post_revision_number"`
+	Title      string `gorm:"size:200;not null"`
+	Tags       string `gorm:"type:text"`
+	Summary    string `gorm:"size:500"`
+	Cover      string `gorm:"size:500"`
+	Body       string `gorm:"type:text"`
+	AuthorID   uint   `gorm:"index"`
+	Author     string `gorm:"size:100"`
+	RollbackOf int
+	CreatedAt  time.Time
+}
+
+func (postRevision) TableName() string { return "post_revision" }
+
+// 文章版本历史，已有文章的当前内容作为第 1 版
+func init() {
+	register(Migration{
+		Version: "20261019000008",
+		Name:    "create_post_revisions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&postRevision{}); err != nil {
+				return err
+			}
+			return tx.Exec(`INSERT INTO post_revision (post_id, number, title, tags, summary, cover, body, author_id, author, rollback_of, created_at)
+				SELECT id, 1, title, tags, summary, cover, body, author_id, author, 0, updated_at FROM post`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postRevision{})
+		},
+	})
+}
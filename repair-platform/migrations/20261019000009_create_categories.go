@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// category 是创建分类表时的表结构快照
+type category struct {
+	ID          uint   `gorm:"primaryKey"`
+	Path        string `gorm:"size:100;not null;uniqueIndex"`
+	Name        string `gorm:"size:100"`
+	Description string `gorm:"size:500"`
+	Sort        int    `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (category) TableName() string { return "category" }
+
+// categoryRedirect 是创建分类重定向表时的表结构快照
+type categoryRedirect struct {
+	ID        uint   `gorm:"primaryKey"`
+	OldPath   string `gorm:"size:100;not null;uniqueIndex"`
+	NewPath   string `gorm:"size:100;not null;index"`
+	CreatedAt time.Time
+}
+
+func (categoryRedirect) TableName() string { return "category_redirect" }
+
+// 多级分类的显示信息，以及分类移动后旧路径的重定向
+func init() {
+	register(Migration{
+		Version: "20261019000009",
+		Name:    "create_categories",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&category{}, &categoryRedirect{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&categoryRedirect{}, &category{})
+		},
+	})
+}
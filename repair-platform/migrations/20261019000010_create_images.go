@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// image 是创建图片表时的表结构快照
+type image struct {
+	ID          uint   `gorm:"primaryKey"`
+	ObjectKey   string `gorm:"size:255;not null;uniqueIndex"`
+	Source      string `gorm:"size:20;not null;index"`
+	UserID      uint   `gorm:"index"`
+	ContentType string `gorm:"size:100"`
+	Width       int
+	Height      int
+	Size        int64
+	Variants    string `gorm:"type:text"`
+	CreatedAt   time.Time
+}
+
+func (image) TableName() string { return "image" }
+
+// 上传图片处理后的尺寸和派生尺寸
+func init() {
+	register(Migration{
+		Version: "20261019000010",
+		Name:    "create_images",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&image{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&image{})
+		},
+	})
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return db
+}
+
+func TestUpDownAndStatus(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := CheckUpToDate(db); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("expected ErrPendingMigrations on empty database, got %v", err)
+	}
+
+	done, err := Up(db, 0)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if len(done) != len(All()) {
+		t.Fatalf("expected %d migrations applied, got %d", len(All()), len(done))
+	}
+	if err := CheckUpToDate(db); err != nil {
+		t.Fatalf("expected schema up to date, got %v", err)
+	}
+	if !db.Migrator().HasIndex("feedback", "idx_feedback_deleted_at") {
+		t.Error("expected feedback deleted_at index")
+	}
+
+	reverted, err := Down(db, 1)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != All()[len(All())-1].Version {
+		t.Fatalf("expected latest migration to be reverted, got %+v", reverted)
+	}
+
+	statuses, err := StatusOf(db)
+	if err != nil {
+		t.Fatalf("StatusOf failed: %v", err)
+	}
+	if !statuses[0].Applied || statuses[len(statuses)-1].Applied {
+		t.Errorf("unexpected statuses after rollback: %+v", statuses)
+	}
+
+	if _, err := Down(db, len(All())); err != nil {
+		t.Fatalf("Down all failed: %v", err)
+	}
+	if db.Migrator().HasTable("user") {
+		t.Error("expected user table to be dropped")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+
+	path, err := Create(dir, "add_user_locale", now)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if filepath.Base(path) != "20261019123000_add_user_locale.go" {
+		t.Errorf("unexpected file name %s", path)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), `Version: "20261019123000"`) {
+		t.Errorf("template missing version:\n%s", content)
+	}
+
+	if _, err := Create(dir, "Bad-Name", now); err == nil {
+		t.Error("expected invalid name to be rejected")
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Category 分类（文件夹）的显示信息，Path 与文章的 Folder 和 Markdown 目录的相对路径一致，多级以 / 分隔
+// 没有记录的文件夹也是分类，显示名为路径的最后一段
+type Category struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Path        string    `gorm:"size:100;not null;uniqueIndex" json:"path"`
+	Name        string    `gorm:"size:100" json:"name"` // 显示名，为空时使用路径的最后一段
+	Description string    `gorm:"size:500" json:"description"`
+	Sort        int       `gorm:"not null;default:0" json:"sort"` // 同级分类按 Sort 升序排列
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CategoryRedirect 分类移动或改名后保留的旧路径，旧路径及其子路径的请求重定向到新路径
+type CategoryRedirect struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OldPath   string    `gorm:"size:100;not null;uniqueIndex" json:"old_path"`
+	NewPath   string    `gorm:"size:100;not null;index" json:"new_path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DisplayName 返回分类的显示名
+func (c *Category) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return BaseName(c.Path)
+}
+
+// BaseName 返回分类路径的最后一段
+func BaseName(p string) string {
+	return p[strings.LastIndex(p, "/")+1:]
+}
+
+// ParentPath 返回上级分类的路径，顶级分类返回空字符串
+func ParentPath(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// InPath 判断 p 是否为 root 本身或其子分类
+func InPath(p, root string) bool {
+	return p == root || strings.HasPrefix(p, root+"/")
+}
@@ -2,18 +2,20 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Feedback 定义了反馈的数据模型
 type Feedback struct {
-	ID        uint `gorm:"primary_key"`
+	ID        uint `gorm:"primaryKey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt *time.Time `sql:"index"`
-	UserID    uint       // 用户ID
-	RepairID  uint       // 关联的维修请求ID
-	Rating    int        // 评分
-	Comments  string     `gorm:"type:varchar(255)"` // 反馈评论
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+	UserID    uint           // 用户ID
+	RepairID  uint           `gorm:"index"` // 关联的维修请求ID
+	Rating    int            // 评分
+	Comments  string         `gorm:"type:varchar(255)"` // 反馈评论
 }
 
 // SetRating 设置反馈评分的逻辑
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// 图片来源
+const (
+	ImageSourceHost   = "image"  // 本地图床，对象位于 images/ 下
+	ImageSourceRepair = "repair" // 报修附件，对象位于上传存储的根目录
+)
+
+// Image 经过处理保存到存储中的图片，记录原图和各派生尺寸的 key 与宽高
+type Image struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ObjectKey   string         `gorm:"size:255;not null;uniqueIndex" json:"key"` // 原图在存储中的 key
+	Source      string         `gorm:"size:20;not null;index" json:"source"`
+	UserID      uint           `gorm:"index" json:"user_id"` // 上传者
+	ContentType string         `gorm:"size:100" json:"content_type"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Size        int64          `json:"size"`
+	Variants    []ImageVariant `gorm:"serializer:json;type:text" json:"variants"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// ImageVariant 图片的一种派生尺寸或格式
+type ImageVariant struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+// Keys 返回原图和全部派生尺寸在存储中的 key
+func (img *Image) Keys() []string {
+	keys := []string{img.ObjectKey}
+	for _, v := range img.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Post 博客文章，正文为 Markdown
+type Post struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Title        string         `gorm:"size:200;not null" json:"title"`
+	Slug         string         `gorm:"size:200;not null;uniqueIndex" json:"slug"` // URL 中使用的唯一标识
+	AuthorID     uint           `gorm:"index" json:"author_id"`
+	Author       string         `gorm:"size:100" json:"author"`                      // 作者名，默认为用户名
+	Folder       string         `gorm:"size:100;index" json:"folder"`                // 所属文件夹（分类）
+	Tags         []string       `gorm:"serializer:json;type:text" json:"tags"`       // 标签列表
+	Summary      string         `gorm:"size:500" json:"summary"`                     // 摘要
+	Cover        string         `gorm:"size:500" json:"cover"`                       // 封面图片地址
+	Status       string         `gorm:"size:20;not null;index" json:"status"`        // 状态: draft, scheduled, published, archived
+	Body         string         `gorm:"type:text" json:"body,omitempty"`             // Markdown 正文
+	SourcePath   string         `gorm:"size:500;index" json:"source_path,omitempty"` // 从文件导入时的相对路径
+	PublishedAt  *time.Time     `json:"published_at"`
+	PublishAt    *time.Time     `gorm:"index" json:"publish_at"` // 定时发布时间，仅 scheduled 状态有效
+	PreviewToken string         `gorm:"size:64;index" json:"-"`  // 预览链接令牌，为空表示未生成
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+// 文章状态
+const (
+	PostDraft     = "draft"     // 草稿
+	PostScheduled = "scheduled" // 等待定时发布
+	PostPublished = "published" // 已发布
+	PostArchived  = "archived"  // 已归档
+)
+
+// IsValidPostStatus 判断文章状态是否合法
+func IsValidPostStatus(status string) bool {
+	switch status {
+	case PostDraft, PostScheduled, PostPublished, PostArchived:
+		return true
+	}
+	return false
+}
+
+// SetStatus 更新文章状态，首次发布时记录发布时间；离开 scheduled 状态时清除定时发布时间
+func (p *Post) SetStatus(status string) {
+	p.Status = status
+	if status != PostScheduled {
+		p.PublishAt = nil
+	}
+	if status == PostPublished && p.PublishedAt == nil {
+		now := time.Now()
+		p.PublishedAt = &now
+	}
+}
+
+// Schedule 设置在 at 时刻定时发布
+func (p *Post) Schedule(at time.Time) {
+	p.Status = PostScheduled
+	p.PublishAt = &at
+}
+
+// PublishScheduled 将到期的定时发布文章改为已发布，发布时间记为计划的时间
+func (p *Post) PublishScheduled() {
+	if p.Status != PostScheduled || p.PublishAt == nil {
+		return
+	}
+	at := *p.PublishAt
+	p.PublishedAt = &at
+	p.SetStatus(PostPublished)
+}
+
+// Slugify 根据标题生成 slug，保留字母、数字和中文，其他字符替换为连字符
+func Slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "post"
+	}
+	return slug
+}
+
+// UniqueSlug 在 base 已被其他文章占用时依次追加 -2、-3 等后缀，excludeID 为正在修改的文章
+// 软删除的文章仍占用唯一索引，因此也计入
+func UniqueSlug(db *gorm.DB, base string, excludeID uint) (string, error) {
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Unscoped().Model(&Post{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = base + "-" + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// PostRevision 文章每次保存内容时的快照，Number 在同一篇文章内从 1 递增
+type PostRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PostID     uint      `gorm:"not null;uniqueIndex:idx_post_revision_number" json:"post_id"`
+	Number     int       `gorm:"not null;uniqueIndex:idx_post_revision_number" json:"number"`
+	Title      string    `gorm:"size:200;not null" json:"title"`
+	Tags       []string  `gorm:"serializer:json;type:text" json:"tags"`
+	Summary    string    `gorm:"size:500" json:"summary"`
+	Cover      string    `gorm:"size:500" json:"cover"`
+	Body       string    `gorm:"type:text" json:"body,omitempty"`
+	AuthorID   uint      `gorm:"index" json:"author_id"` // 保存该版本的用户
+	Author     string    `gorm:"size:100" json:"author"`
+	RollbackOf int       `json:"rollback_of,omitempty"` // 由回滚生成时为被恢复的版本号
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewPostRevision 根据文章当前内容生成快照，Number 由保存时确定
+func NewPostRevision(p *Post, editor User) PostRevision {
+	return PostRevision{
+		PostID:   p.ID,
+		Title:    p.Title,
+		Tags:     p.Tags,
+		Summary:  p.Summary,
+		Cover:    p.Cover,
+		Body:     p.Body,
+		AuthorID: editor.ID,
+		Author:   editor.Username,
+	}
+}
+
+// SameContent 判断快照内容是否与另一个快照相同
+func (r *PostRevision) SameContent(o *PostRevision) bool {
+	if r.Title != o.Title || r.Summary != o.Summary || r.Cover != o.Cover || r.Body != o.Body || len(r.Tags) != len(o.Tags) {
+		return false
+	}
+	for i := range r.Tags {
+		if r.Tags[i] != o.Tags[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Restore 将快照内容写回文章
+func (r *PostRevision) Restore(p *Post) {
+	p.Title = r.Title
+	p.Tags = r.Tags
+	p.Summary = r.Summary
+	p.Cover = r.Cover
+	p.Body = r.Body
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"gorm.io/gorm" // 导入新版 GORM 库
-	"time"         // 用于处理时间类型
+	"path"
+	"strings"
+	"time" // 用于处理时间类型
 )
 
 // RepairRequest 表示一个用户提交的维修请求
@@ -14,7 +16,7 @@ type RepairRequest struct {
 	Status       string     `json:"status"`        // 报修状态：pending, in_progress, completed
 	Location     string     `json:"location"`      // 报修的位置
 	Priority     string     `json:"priority"`      // 紧急程度：low, medium, high
-	ImageURL     string     `json:"image_url"`     // 上传的报修相关图片（可选）
+	ImageURL     string     `json:"image_url"`     // 上传的报修相关图片在存储中的 key（可选），早期记录为本地文件路径
 	CompletedAt  *time.Time `json:"completed_at"`  // 任务完成时间（可为空）
 }
 
@@ -33,3 +35,8 @@ func (r *RepairRequest) SetStatus(status string) {
 		r.CompletedAt = &now
 	}
 }
+
+// AttachmentKey 返回 ImageURL 对应的存储 key，早期记录保存的是上传目录下的文件路径，取文件名即为 key
+func AttachmentKey(imageURL string) string {
+	return path.Base(strings.ReplaceAll(imageURL, "\\", "/"))
+}
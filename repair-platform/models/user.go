@@ -16,11 +16,28 @@ type User struct {
 	Email      string `gorm:"unique;not null"`
 	Role       string `gorm:"not null"` // 角色: user, technician, admin
 	IsVerified bool   `gorm:"default:false"`
+	Locale     string `gorm:"size:10"` // 语言偏好: zh-CN, en，为空时按 Accept-Language 选择
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
 }
 
+// 用户角色
+const (
+	RoleUser       = "user"       // 普通用户
+	RoleTechnician = "technician" // 维修人员
+	RoleAdmin      = "admin"      // 管理员
+)
+
+// IsValidRole 判断角色名是否合法
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleTechnician, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // RegisterInput 是用于注册的输入结构体
 type RegisterInput struct {
 	Username string `json:"username" binding:"required"`
@@ -50,14 +67,17 @@ func (user *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
-// GenerateJWT 为用户生成JWT
-func GenerateJWT(username string, role string) (string, error) {
+// GenerateJWT 为用户生成JWT，secret 为签名密钥，ttl 为有效期，locale 为空时不写入语言偏好
+func GenerateJWT(username string, role string, locale string, secret string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"username": username,
 		"role":     role,
-		"exp":      time.Now().Add(72 * time.Hour).Unix(),
+		"exp":      time.Now().Add(ttl).Unix(),
+	}
+	if locale != "" {
+		claims["locale"] = locale
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte("JNU_technicians_club"))
+	return token.SignedString([]byte(secret))
 }
@@ -1,22 +1,77 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"repair-platform/apperror"
+	"repair-platform/config"
 	"repair-platform/controllers"
+	"repair-platform/imagehost"
+	"repair-platform/metrics"
 	"repair-platform/middleware"
 	"repair-platform/service"
+	"repair-platform/storage"
 )
 
 // SetupRoutes 设置应用程序的路由和中间件
-func SetupRoutes(r *gin.Engine, db *gorm.DB, emailService service.EmailService) {
+func SetupRoutes(r *gin.Engine, db *gorm.DB, emailService service.EmailService, store storage.Storage, cfg *config.Config) {
+	if cfg.Tracing.Enabled {
+		// 放在最前面，后续中间件和处理函数的 span 都挂在请求 span 下
+		r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(tracingFilter)))
+	}
+	r.Use(middleware.RequestLogger(zap.L())) // 请求 ID 和请求级日志
+	r.Use(middleware.Locale())               // 按 Accept-Language 选择响应语言
+	r.Use(middleware.Recovery())             // panic 转为统一格式的 500 错误
+
+	// 校验错误中的字段名使用 json/form 标签
+	apperror.RegisterValidator()
+
+	// 未匹配的路由也返回统一的错误格式
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(func(c *gin.Context) { apperror.Abort(c, apperror.ErrNotFound) })
+	r.NoMethod(func(c *gin.Context) { apperror.Abort(c, apperror.ErrMethodNotAllowed) })
+
+	if cfg.Metrics.Enabled {
+		r.Use(metrics.Middleware())
+		r.GET("/metrics", metrics.Handler(cfg.Metrics.Token)) // Prometheus 指标，不需要 JWT
+	}
+
+	images := imagehost.New(cfg.ImageHost, store)
 	r.Use(func(c *gin.Context) {
-		c.Set("db", db)
+		c.Set("db", db.WithContext(c.Request.Context())) // 查询随请求取消，并挂到请求的 span 下
+		c.Set("config", cfg)
+		c.Set("emailService", emailService)
+		c.Set("storage", store)
+		c.Set("imageHost", images)
 		c.Next()
 	})
 
-	setupAuthRoutes(r)      // 用户认证相关路由
-	setupProtectedRoutes(r) // 需要 JWT 授权的路由
+	setupHealthRoutes(r)                        // 健康检查和构建信息，不需要认证
+	setupAuthRoutes(r)                          // 用户认证相关路由
+	setupPublicRoutes(r)                        // 已发布文章、订阅源和站点地图，不需要认证
+	setupSignedFileRoutes(r)                    // 签名下载地址，凭签名访问，不需要认证
+	setupMediaRoutes(r)                         // 本地图床的图片，不需要认证
+	setupProtectedRoutes(r, cfg.Auth.JWTSecret) // 需要 JWT 授权的路由
+}
+
+// tracingFilter 不为探活和指标抓取请求创建 span
+func tracingFilter(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
+
+// 设置健康检查路由，供反向代理和 systemd watchdog 使用
+func setupHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", controllers.Healthz) // 存活检查
+	r.GET("/readyz", controllers.Readyz)   // 就绪检查
+	r.GET("/version", controllers.Version) // 构建信息
 }
 
 // 设置用户认证路由
@@ -28,17 +83,51 @@ func setupAuthRoutes(r *gin.Engine) {
 	r.POST("/api/verify_email", controllers.VerifyEmail)                    // 验证邮箱
 }
 
+// 设置公开路由，只返回已发布的文章，允许浏览器和 CDN 缓存
+func setupPublicRoutes(r *gin.Engine) {
+	cache := middleware.CacheControl("public, max-age=300")
+	r.GET("/rss.xml", cache, controllers.RSSFeed)   // 全站 RSS 2.0 订阅源
+	r.GET("/atom.xml", cache, controllers.AtomFeed) // 全站 Atom 1.0 订阅源
+	r.GET("/sitemap.xml", cache, controllers.Sitemap)
+
+	publicRoutes := r.Group("/api/public")
+	publicRoutes.Use(cache)
+	{
+		publicRoutes.GET("/posts", controllers.ListPosts) // 未登录时只列出已发布的文章
+		publicRoutes.GET("/posts/:slug", controllers.GetPublicPost)
+		publicRoutes.GET("/folders/*path", controllers.FolderFeed) // 文件夹订阅源，如 /folders/guides/phone/rss.xml
+		publicRoutes.GET("/categories", controllers.ListCategories)
+		publicRoutes.GET("/categories/*path", controllers.GetCategory) // 多级分类页面，已移动的分类重定向到新路径
+		publicRoutes.GET("/tags", controllers.ListTags)
+		publicRoutes.GET("/tags/:tag", controllers.GetTag)
+	}
+}
+
+// 设置签名下载地址的路由，本地和内存存储签发的下载地址由此提供文件
+func setupSignedFileRoutes(r *gin.Engine) {
+	r.GET(storage.SignedPathPrefix+"*key", controllers.GetSignedFile)
+}
+
+// 设置本地图床的图片路由，只公开存储中 images/ 下的对象
+func setupMediaRoutes(r *gin.Engine) {
+	r.GET("/media/*path", controllers.GetMedia)
+}
+
 // 设置需要 JWT 授权的路由组
-func setupProtectedRoutes(r *gin.Engine) {
+func setupProtectedRoutes(r *gin.Engine, jwtSecret string) {
 	authRoutes := r.Group("/api")
-	authRoutes.Use(middleware.JWTAuthMiddleware())
+	authRoutes.Use(middleware.JWTAuthMiddleware(jwtSecret))
 	{
 		setupRepairRoutes(authRoutes)   // 报修请求路由
 		setupFeedbackRoutes(authRoutes) // 用户反馈路由
 		setupUploadRoutes(authRoutes)   // 文件上传路由
 		setupImageUploadRoutes(authRoutes)
-		setupFolderUploadRoutes(authRoutes) // 文件夹管理路由
-		setupMarkdownRoutes(authRoutes)     // Markdown 文件内容获取路由
+		setupFolderUploadRoutes(authRoutes)           // 文件夹管理路由
+		setupMarkdownRoutes(authRoutes)               // Markdown 文件内容获取路由
+		setupPostRoutes(authRoutes)                   // 文章查询路由
+		authRoutes.GET("/search", controllers.Search) // 全文检索
+
+		authRoutes.PUT("/profile/locale", controllers.UpdateLocale) // 修改语言偏好
 
 		// 管理员专属的路由组
 		adminRoutes := authRoutes.Group("/admin")
@@ -46,6 +135,12 @@ func setupProtectedRoutes(r *gin.Engine) {
 		{
 			adminRoutes.GET("/repair_requests", controllers.AdminListRepairRequests)
 			adminRoutes.PUT("/repair_requests/:id", controllers.AdminUpdateRepairRequest)
+			adminRoutes.GET("/repair_requests/:id/attachment", controllers.GetRepairAttachment) // 重定向到附件的签名下载地址
+			adminRoutes.GET("/log-level", controllers.GetLogLevel)
+			adminRoutes.PUT("/log-level", controllers.SetLogLevel)
+			setupAdminPostRoutes(adminRoutes)
+			adminRoutes.PUT("/categories", controllers.SaveCategory)
+			adminRoutes.POST("/categories/move", controllers.MoveCategory) // 移动或改名，旧路径保留重定向
 		}
 	}
 }
@@ -57,21 +152,50 @@ func setupUploadRoutes(r *gin.RouterGroup) {
 
 // 设置图片上传路由（受保护）
 func setupImageUploadRoutes(r *gin.RouterGroup) {
-	r.POST("/upload/image", controllers.UploadImage) // 图片上传至图床的路由
+	r.POST("/upload/image", controllers.UploadImage) // 按配置保存到本地图床或上传到 sm.ms
 }
 
 // 添加文件夹管理路由
 func setupFolderUploadRoutes(r *gin.RouterGroup) {
 	r.GET("/upload/folders", controllers.GetFolders)    // 获取文件夹列表
 	r.POST("/upload/folders", controllers.CreateFolder) // 创建新文件夹
+	r.PATCH("/upload/folders", controllers.RenameFolder)
+	r.DELETE("/upload/folders", controllers.DeleteFolder) // 非空文件夹需要 recursive=true 和 confirm
 }
 
 // 添加 Markdown 文件内容路由
 func setupMarkdownRoutes(r *gin.RouterGroup) {
-	// 获取指定 Markdown 文件内容
+	// 获取指定 Markdown 文件内容，format=html 时附带渲染结果
 	r.GET("/markdown/:file", controllers.GetMarkdownContent) // 获取 Markdown 文件内容
 	// 列出指定文件夹下的所有 Markdown 文件
-	r.GET("/markdown/files/:folder", controllers.ListMarkdownFiles) // 获取 Markdown 文件列表
+	r.GET("/markdown/files/*folder", controllers.ListMarkdownFiles) // 获取 Markdown 文件列表，支持多级文件夹
+	r.POST("/markdown/render", controllers.RenderMarkdown)          // 渲染预览
+	r.PATCH("/markdown/:file", controllers.MoveMarkdownFile)        // 改名或移动（仅管理员）
+	r.DELETE("/markdown/:file", controllers.DeleteMarkdownFile)     // 删除（仅管理员）
+}
+
+// 设置文章查询路由，非管理员只能看到已发布的文章
+func setupPostRoutes(r *gin.RouterGroup) {
+	r.GET("/posts", controllers.ListPosts)
+	r.GET("/posts/:id", controllers.GetPost)
+	r.GET("/posts/preview/:token", controllers.GetPostPreview) // 持有预览链接即可查看未发布的文章
+}
+
+// 设置文章管理路由（仅管理员）
+func setupAdminPostRoutes(r *gin.RouterGroup) {
+	r.POST("/posts", controllers.CreatePost)
+	r.POST("/posts/import", controllers.ImportPosts) // 从 Markdown 目录导入
+	r.PUT("/posts/:id", controllers.UpdatePost)
+	r.DELETE("/posts/:id", controllers.DeletePost)
+	r.POST("/posts/:id/publish", controllers.PublishPost) // 立即发布或定时发布
+	r.POST("/posts/:id/unpublish", controllers.UnpublishPost)
+	r.POST("/posts/:id/archive", controllers.ArchivePost)
+	r.POST("/posts/:id/preview", controllers.CreatePostPreview)
+	r.DELETE("/posts/:id/preview", controllers.RevokePostPreview)
+	r.GET("/posts/:id/revisions", controllers.ListPostRevisions)
+	r.GET("/posts/:id/revisions/:number", controllers.GetPostRevision)
+	r.POST("/posts/:id/revisions/:number/rollback", controllers.RollbackPost)
+	r.GET("/posts/:id/diff", controllers.DiffPostRevisions) // from/to 为版本号
 }
 
 // 设置报修请求相关路由
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lock 是 scheduler_locks 表中的一行，每个任务一行
+type Lock struct {
+	Name        string    `gorm:"primaryKey;size:100"`
+	Holder      string    `gorm:"size:255;not null"`
+	LockedUntil time.Time `gorm:"not null"`
+}
+
+// TableName 自定义表名
+func (Lock) TableName() string {
+	return "scheduler_locks"
+}
+
+// DBLocker 使用数据库行实现任务锁，适用于所有支持的数据库
+type DBLocker struct {
+	db *gorm.DB
+}
+
+// NewDBLocker 创建基于数据库的任务锁
+func NewDBLocker(db *gorm.DB) *DBLocker {
+	return &DBLocker{db: db}
+}
+
+// Acquire 锁不存在、已过期或由 holder 持有时获取成功
+func (l *DBLocker) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	db := l.db.WithContext(ctx)
+
+	// 首次执行时插入锁记录
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Lock{Name: name, Holder: holder, LockedUntil: now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	// 条件更新保证只有一个实例能抢到过期的锁
+	result = db.Model(&Lock{}).
+		Where("name = ? AND (locked_until < ? OR holder = ?)", name, now, holder).
+		Updates(map[string]interface{}{"holder": holder, "locked_until": now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// acquireScript 原子地获取或续期锁
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// RedisLocker 使用 Redis 键实现任务锁
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLocker 创建基于 Redis 的任务锁
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client, prefix: "scheduler:lock:"}
+}
+
+// Acquire 键不存在或由 holder 持有时获取成功
+func (l *RedisLocker) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	n, err := acquireScript.Run(ctx, l.client, []string{l.prefix + name}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
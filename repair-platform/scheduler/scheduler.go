@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// Job 是一个按 cron 表达式周期执行的任务
+type Job struct {
+	Name string
+	// Spec 为标准 5 段 cron 表达式（分 时 日 月 周），也支持 @hourly、@every 1h 等写法
+	Spec string
+	Run  func(ctx context.Context) error
+}
+
+// Locker 保证多实例部署时同一任务在同一时刻只有一个实例执行
+// 锁在 ttl 到期前一直由 holder 持有，holder 可以重复获取自己的锁
+type Locker interface {
+	Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+}
+
+// entry 是已注册任务的运行状态
+type entry struct {
+	job      Job
+	schedule cron.Schedule
+	next     time.Time
+	running  atomic.Bool
+}
+
+// Scheduler 按计划触发任务，每次执行前先获取分布式锁
+type Scheduler struct {
+	locker  Locker
+	lockTTL time.Duration
+	holder  string
+	logger  *zap.SugaredLogger
+	entries []*entry
+}
+
+// New 创建调度器，lockTTL 为锁持有时间的上限，应大于任务的最长执行时间
+// 每次执行实际使用的锁时间不超过距下次执行间隔的一半，持有锁的实例崩溃后，下一次执行可由其他实例接管
+func New(locker Locker, lockTTL time.Duration, logger *zap.SugaredLogger) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		locker:  locker,
+		lockTTL: lockTTL,
+		holder:  fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		logger:  logger,
+	}
+}
+
+// ParseSpec 解析 cron 表达式
+func ParseSpec(spec string) (cron.Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// Add 注册任务，表达式非法时返回错误
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job name and run function are required")
+	}
+	schedule, err := ParseSpec(job.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", job.Spec, job.Name, err)
+	}
+	s.entries = append(s.entries, &entry{job: job, schedule: schedule})
+	return nil
+}
+
+// Run 运行调度循环直到 ctx 取消，返回前等待正在执行的任务结束
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	now := time.Now()
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+		s.logger.Infof("定时任务 %s 已注册, 计划: %s, 下次执行: %s", e.job.Name, e.job.Spec, e.next.Format(time.RFC3339))
+	}
+
+	for {
+		timer := time.NewTimer(s.untilNext())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for _, e := range s.entries {
+			if e.next.After(now) {
+				continue
+			}
+			e.next = e.schedule.Next(now)
+			ttl := s.lockTTLUntil(e.next, now)
+			if !e.running.CompareAndSwap(false, true) {
+				s.logger.Warnf("定时任务 %s 上一次执行尚未结束，跳过本次", e.job.Name)
+				continue
+			}
+			wg.Add(1)
+			go func(e *entry) {
+				defer wg.Done()
+				defer e.running.Store(false)
+				s.execute(ctx, e.job, ttl)
+			}(e)
+		}
+	}
+}
+
+// untilNext 返回距离最近一个任务触发的时间
+func (s *Scheduler) untilNext() time.Duration {
+	if len(s.entries) == 0 {
+		return time.Hour
+	}
+	earliest := s.entries[0].next
+	for _, e := range s.entries[1:] {
+		if e.next.Before(earliest) {
+			earliest = e.next
+		}
+	}
+	if d := time.Until(earliest); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// lockTTLUntil 返回本次执行使用的锁时间：不超过 lockTTL，也不超过距下次执行 next 的一半
+func (s *Scheduler) lockTTLUntil(next, now time.Time) time.Duration {
+	if half := next.Sub(now) / 2; half > 0 && half < s.lockTTL {
+		return half
+	}
+	return s.lockTTL
+}
+
+// execute 获取锁后执行任务，锁持有 ttl
+func (s *Scheduler) execute(ctx context.Context, job Job, ttl time.Duration) {
+	acquired, err := s.locker.Acquire(ctx, job.Name, s.holder, ttl)
+	if err != nil {
+		s.logger.Errorf("定时任务 %s 获取锁失败: %v", job.Name, err)
+		return
+	}
+	if !acquired {
+		s.logger.Infof("定时任务 %s 正由其他实例执行，跳过", job.Name)
+		return
+	}
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.Errorf("定时任务 %s 执行失败: %v", job.Name, err)
+		return
+	}
+	s.logger.Infof("定时任务 %s 执行完成, 耗时: %s", job.Name, time.Since(start))
+}
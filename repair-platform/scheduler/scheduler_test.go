@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDBLocker(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lock.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Lock{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	locker := NewDBLocker(db)
+	ctx := context.Background()
+
+	acquire := func(holder string, ttl time.Duration) bool {
+		t.Helper()
+		ok, err := locker.Acquire(ctx, "job", holder, ttl)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		return ok
+	}
+
+	if !acquire("a", time.Minute) {
+		t.Fatal("first acquire should succeed")
+	}
+	if acquire("b", time.Minute) {
+		t.Fatal("acquire by another holder should fail while lock is held")
+	}
+	if !acquire("a", time.Millisecond) {
+		t.Fatal("holder should be able to renew its own lock")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !acquire("b", time.Minute) {
+		t.Fatal("acquire should succeed after lock expired")
+	}
+}
+
+type fakeLocker struct{ allow bool }
+
+func (l fakeLocker) Acquire(context.Context, string, string, time.Duration) (bool, error) {
+	return l.allow, nil
+}
+
+func TestSchedulerRunsDueJobs(t *testing.T) {
+	if err := New(fakeLocker{}, time.Minute, zap.NewNop().Sugar()).Add(Job{Name: "bad", Spec: "not a cron", Run: func(context.Context) error { return nil }}); err == nil {
+		t.Fatal("expected invalid spec to be rejected")
+	}
+
+	for _, allow := range []bool{true, false} {
+		s := New(fakeLocker{allow: allow}, time.Minute, zap.NewNop().Sugar())
+		var runs atomic.Int32
+		if err := s.Add(Job{Name: "tick", Spec: "@every 1s", Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+		_ = s.Run(ctx)
+		cancel()
+
+		if got := runs.Load(); (got > 0) != allow {
+			t.Errorf("allow=%v: unexpected run count %d", allow, got)
+		}
+	}
+}
+
+func TestLockTTLCappedByInterval(t *testing.T) {
+	s := New(fakeLocker{}, 10*time.Minute, zap.NewNop().Sugar())
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		next time.Time
+		want time.Duration
+	}{
+		{now.Add(time.Minute), 30 * time.Second},
+		{now.Add(time.Hour), 10 * time.Minute},
+		{now, 10 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := s.lockTTLUntil(c.next, now); got != c.want {
+			t.Errorf("next in %s: expected ttl %s, got %s", c.next.Sub(now), c.want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"repair-platform/models"
+
+	"gorm.io/gorm"
+)
+
+// repairTitleLength 报修请求没有标题，使用描述开头的字符作为标题
+const repairTitleLength = 40
+
+// PostDocument 将文章转换为索引文档
+func PostDocument(p *models.Post) Document {
+	return Document{
+		Kind:      KindPost,
+		ID:        p.ID,
+		Title:     p.Title,
+		Body:      p.Summary + "\n" + p.Body,
+		Tags:      p.Tags,
+		Published: p.Status == models.PostPublished,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// RepairDocument 将报修请求及其反馈评论转换为索引文档
+func RepairDocument(r *models.RepairRequest, feedback []models.Feedback) Document {
+	title := []rune(strings.Join(strings.Fields(r.Description), " "))
+	if len(title) > repairTitleLength {
+		title = append(title[:repairTitleLength], '…')
+	}
+	parts := []string{r.Description, r.Location}
+	for _, f := range feedback {
+		parts = append(parts, f.Comments)
+	}
+	return Document{
+		Kind:      KindRepair,
+		ID:        r.ID,
+		Title:     string(title),
+		Body:      strings.Join(parts, "\n"),
+		OwnerID:   r.UserID,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// Rebuild 从数据库重新构建全部索引，返回索引的文档数
+func (ix *Index) Rebuild(ctx context.Context, db *gorm.DB) (int, error) {
+	db = db.WithContext(ctx)
+
+	var posts []models.Post
+	if err := db.Find(&posts).Error; err != nil {
+		return 0, fmt.Errorf("failed to load posts: %w", err)
+	}
+	var repairs []models.RepairRequest
+	if err := db.Find(&repairs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load repair requests: %w", err)
+	}
+	var feedback []models.Feedback
+	if err := db.Where("comments <> ''").Find(&feedback).Error; err != nil {
+		return 0, fmt.Errorf("failed to load feedback: %w", err)
+	}
+	byRepair := make(map[uint][]models.Feedback)
+	for _, f := range feedback {
+		byRepair[f.RepairID] = append(byRepair[f.RepairID], f)
+	}
+
+	docs := make([]Document, 0, len(posts)+len(repairs))
+	for i := range posts {
+		docs = append(docs, PostDocument(&posts[i]))
+	}
+	for i := range repairs {
+		docs = append(docs, RepairDocument(&repairs[i], byRepair[repairs[i].ID]))
+	}
+	ix.Replace(docs)
+	return len(docs), nil
+}
+
+// SyncRepair 从数据库重新索引单个报修请求，报修请求不存在（已删除）时从索引中移除
+func (ix *Index) SyncRepair(ctx context.Context, db *gorm.DB, id uint) error {
+	db = db.WithContext(ctx)
+
+	var repair models.RepairRequest
+	if err := db.First(&repair, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ix.Remove(KindRepair, id)
+			return nil
+		}
+		return fmt.Errorf("failed to load repair request: %w", err)
+	}
+	var feedback []models.Feedback
+	if err := db.Where("repair_id = ? AND comments <> ''", id).Find(&feedback).Error; err != nil {
+		return fmt.Errorf("failed to load feedback: %w", err)
+	}
+	ix.Put(RepairDocument(&repair, feedback))
+	return nil
+}
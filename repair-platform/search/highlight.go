@@ -0,0 +1,108 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// snippetLength 摘要片段的字符数
+const snippetLength = 120
+
+// matchMask 标记 text 中与任一查询片段匹配的字符（不区分大小写）
+func matchMask(text []rune, terms []string) []bool {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	needles := make([][]rune, 0, len(terms))
+	for _, t := range terms {
+		if t != "" {
+			needles = append(needles, []rune(t))
+		}
+	}
+
+	mask := make([]bool, len(text))
+	for i := range lower {
+		for _, n := range needles {
+			if i+len(n) > len(lower) || !runesEqual(lower[i:i+len(n)], n) {
+				continue
+			}
+			for j := i; j < i+len(n); j++ {
+				mask[j] = true
+			}
+		}
+	}
+	return mask
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// markHTML 转义 text 并用 <mark> 包裹匹配的字符
+func markHTML(text []rune, mask []bool) string {
+	var b strings.Builder
+	inMark := false
+	for i, r := range text {
+		if mask[i] != inMark {
+			if mask[i] {
+				b.WriteString("<mark>")
+			} else {
+				b.WriteString("</mark>")
+			}
+			inMark = mask[i]
+		}
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	if inMark {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
+
+// Highlight 返回转义后的 HTML，匹配查询的部分用 <mark> 包裹
+func Highlight(text, query string) string {
+	runes := []rune(text)
+	return markHTML(runes, matchMask(runes, terms(query)))
+}
+
+// Snippet 截取第一个匹配附近的片段并高亮，没有匹配时返回开头部分
+func Snippet(text, query string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	mask := matchMask(runes, terms(query))
+
+	first := 0
+	for i, m := range mask {
+		if m {
+			first = i
+			break
+		}
+	}
+	// 匹配位置之前保留三分之一的上下文
+	start := first - snippetLength/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+		if start = end - snippetLength; start < 0 {
+			start = 0
+		}
+	}
+
+	s := markHTML(runes[start:end], mask[start:end])
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(runes) {
+		s += "…"
+	}
+	return s
+}
@@ -0,0 +1,294 @@
+// Package search 提供文章和报修请求的进程内全文检索
+// 索引保存在内存中，启动时从数据库构建，数据修改时由调用方同步更新
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"repair-platform/models"
+)
+
+// Kind 文档类型
+type Kind string
+
+// 支持检索的文档类型
+const (
+	KindPost   Kind = "post"
+	KindRepair Kind = "repair"
+)
+
+// 各字段的词频权重
+const (
+	titleWeight = 3
+	tagWeight   = 2
+	bodyWeight  = 1
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Document 被索引的文档
+type Document struct {
+	Kind  Kind
+	ID    uint
+	Title string
+	Body  string
+	Tags  []string
+
+	// 以下字段用于按角色过滤结果
+	Published bool // 文章是否已发布
+	OwnerID   uint // 报修请求的提交人
+
+	UpdatedAt time.Time
+}
+
+// Viewer 发起检索的用户，决定哪些文档可见
+type Viewer struct {
+	UserID uint
+	Role   string // user, technician, admin
+}
+
+// CanSee 管理员可见全部文档；维修人员可见已发布文章和全部报修请求；普通用户只能看到已发布文章和自己的报修请求
+func (v Viewer) CanSee(doc *Document) bool {
+	switch doc.Kind {
+	case KindPost:
+		return doc.Published || v.Role == models.RoleAdmin
+	case KindRepair:
+		return v.Role == models.RoleAdmin || v.Role == models.RoleTechnician || (doc.OwnerID != 0 && doc.OwnerID == v.UserID)
+	}
+	return false
+}
+
+// Query 检索条件
+type Query struct {
+	Text   string
+	Kinds  []Kind // 为空时检索全部类型
+	Viewer Viewer
+	Offset int
+	Limit  int
+}
+
+// Hit 一条检索结果，Title 和 Snippet 是已转义并高亮的 HTML
+type Hit struct {
+	Kind      Kind      `json:"kind"`
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Result 检索结果，Total 为过滤后的命中总数
+type Result struct {
+	Hits  []Hit `json:"hits"`
+	Total int   `json:"total"`
+}
+
+// docKey 文档在索引中的唯一标识
+type docKey struct {
+	kind Kind
+	id   uint
+}
+
+// entry 已索引的文档及其加权长度
+type entry struct {
+	doc    *Document
+	length int
+}
+
+// Index 倒排索引，可并发使用
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[docKey]*entry
+	postings    map[string]map[docKey]int // 检索词 -> 文档 -> 加权词频
+	totalLength int
+}
+
+// Default 服务使用的索引
+var Default = NewIndex()
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[docKey]*entry),
+		postings: make(map[string]map[docKey]int),
+	}
+}
+
+// Len 返回已索引的文档数
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Put 添加或替换文档
+func (ix *Index) Put(doc Document) {
+	key := docKey{doc.Kind, doc.ID}
+	freqs, length := termFrequencies(&doc)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(key)
+	ix.docs[key] = &entry{doc: &doc, length: length}
+	ix.totalLength += length
+	for term, tf := range freqs {
+		p := ix.postings[term]
+		if p == nil {
+			p = make(map[docKey]int)
+			ix.postings[term] = p
+		}
+		p[key] = tf
+	}
+}
+
+// Remove 删除文档，文档不存在时不做任何事
+func (ix *Index) Remove(kind Kind, id uint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(docKey{kind, id})
+}
+
+// Replace 用 docs 替换索引中的全部文档
+func (ix *Index) Replace(docs []Document) {
+	fresh := NewIndex()
+	for _, doc := range docs {
+		fresh.Put(doc)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.docs, ix.postings, ix.totalLength = fresh.docs, fresh.postings, fresh.totalLength
+}
+
+// remove 删除文档，调用方需持有写锁
+func (ix *Index) remove(key docKey) {
+	e, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	freqs, _ := termFrequencies(e.doc)
+	for term := range freqs {
+		if p := ix.postings[term]; p != nil {
+			delete(p, key)
+			if len(p) == 0 {
+				delete(ix.postings, term)
+			}
+		}
+	}
+	ix.totalLength -= e.length
+	delete(ix.docs, key)
+}
+
+// termFrequencies 计算文档中每个检索词的加权词频和文档加权长度
+func termFrequencies(doc *Document) (map[string]int, int) {
+	freqs := make(map[string]int)
+	length := 0
+	add := func(text string, weight int) {
+		for _, t := range Tokenize(text) {
+			freqs[t] += weight
+			length += weight
+		}
+	}
+	add(doc.Title, titleWeight)
+	for _, tag := range doc.Tags {
+		add(tag, tagWeight)
+	}
+	add(doc.Body, bodyWeight)
+	return freqs, length
+}
+
+// Search 返回包含所有检索词且对 Viewer 可见的文档，按 BM25 得分排序
+func (ix *Index) Search(q Query) Result {
+	tokens := unique(Tokenize(q.Text))
+	if len(tokens) == 0 {
+		return Result{Hits: []Hit{}}
+	}
+	kinds := make(map[Kind]bool, len(q.Kinds))
+	for _, k := range q.Kinds {
+		kinds[k] = true
+	}
+
+	type scored struct {
+		doc   *Document
+		score float64
+	}
+	var matches []scored
+
+	ix.mu.RLock()
+	// 从文档最少的检索词开始求交集
+	sort.Slice(tokens, func(i, j int) bool { return len(ix.postings[tokens[i]]) < len(ix.postings[tokens[j]]) })
+	n := float64(len(ix.docs))
+	avgLength := 1.0
+	if len(ix.docs) > 0 {
+		avgLength = float64(ix.totalLength) / n
+	}
+	for key := range ix.postings[tokens[0]] {
+		e := ix.docs[key]
+		if (len(kinds) > 0 && !kinds[key.kind]) || !q.Viewer.CanSee(e.doc) {
+			continue
+		}
+		score := 0.0
+		for _, t := range tokens {
+			tf, ok := ix.postings[t][key]
+			if !ok {
+				score = -1
+				break
+			}
+			df := float64(len(ix.postings[t]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(e.length)/avgLength)
+			score += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + norm)
+		}
+		if score >= 0 {
+			matches = append(matches, scored{e.doc, score})
+		}
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].doc.UpdatedAt.After(matches[j].doc.UpdatedAt)
+	})
+
+	result := Result{Hits: []Hit{}, Total: len(matches)}
+	if q.Offset >= len(matches) {
+		return result
+	}
+	matches = matches[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(matches) {
+		matches = matches[:q.Limit]
+	}
+	for _, m := range matches {
+		result.Hits = append(result.Hits, Hit{
+			Kind:      m.doc.Kind,
+			ID:        m.doc.ID,
+			Title:     Highlight(m.doc.Title, q.Text),
+			Snippet:   Snippet(m.doc.Body, q.Text),
+			Score:     math.Round(m.score*1000) / 1000,
+			UpdatedAt: m.doc.UpdatedAt,
+		})
+	}
+	return result
+}
+
+// unique 去掉重复的检索词
+func unique(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	result := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Steam一键解锁游戏, PowerShell 脚本！")
+	want := []string{"steam", "一键", "键解", "解锁", "锁游", "游戏", "powershell", "脚本"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := Tokenize("修 a"); !reflect.DeepEqual(got, []string{"修", "a"}) {
+		t.Errorf("expected single CJK character to be kept, got %v", got)
+	}
+}
+
+func TestSearchRankingAndFiltering(t *testing.T) {
+	ix := NewIndex()
+	ix.Put(Document{Kind: KindPost, ID: 1, Title: "Steam 解锁游戏骗局", Body: "通过 powershell 解锁游戏", Published: true})
+	ix.Put(Document{Kind: KindPost, ID: 2, Title: "其他文章", Body: "顺便提到解锁游戏", Published: true})
+	ix.Put(Document{Kind: KindPost, ID: 3, Title: "解锁游戏草稿", Published: false})
+	ix.Put(Document{Kind: KindRepair, ID: 1, Title: "电脑无法开机", Body: "电脑无法开机\n宿舍 3 楼", OwnerID: 10})
+
+	user := Viewer{UserID: 10, Role: "user"}
+	res := ix.Search(Query{Text: "解锁游戏", Viewer: user})
+	if res.Total != 2 || res.Hits[0].ID != 1 || res.Hits[1].ID != 2 {
+		t.Fatalf("expected title match first and draft hidden, got %+v", res)
+	}
+	if res.Hits[0].Title != "Steam <mark>解锁游戏</mark>骗局" {
+		t.Errorf("unexpected highlight %q", res.Hits[0].Title)
+	}
+
+	if res := ix.Search(Query{Text: "解锁游戏", Viewer: Viewer{Role: "admin"}}); res.Total != 3 {
+		t.Errorf("expected admin to see drafts, got %d", res.Total)
+	}
+
+	// 所有检索词都必须出现
+	if res := ix.Search(Query{Text: "解锁 电脑", Viewer: Viewer{Role: "admin"}}); res.Total != 0 {
+		t.Errorf("expected no documents containing both terms, got %+v", res)
+	}
+
+	// 报修请求只对提交人、维修人员和管理员可见
+	if res := ix.Search(Query{Text: "开机", Viewer: user}); res.Total != 1 {
+		t.Errorf("expected owner to see own repair request, got %d", res.Total)
+	}
+	if res := ix.Search(Query{Text: "开机", Viewer: Viewer{UserID: 11, Role: "user"}}); res.Total != 0 {
+		t.Errorf("expected other users not to see repair request, got %d", res.Total)
+	}
+	if res := ix.Search(Query{Text: "开机", Kinds: []Kind{KindPost}, Viewer: Viewer{Role: "technician"}}); res.Total != 0 {
+		t.Errorf("expected kind filter to exclude repairs, got %d", res.Total)
+	}
+
+	ix.Remove(KindPost, 1)
+	if res := ix.Search(Query{Text: "steam", Viewer: user}); res.Total != 0 {
+		t.Errorf("expected removed document to be gone, got %+v", res)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	body := "<b>" + strings.Repeat("无关内容", 50) + "关键字在这里" + strings.Repeat("其他", 50)
+	s := Snippet(body, "关键字")
+	if !strings.Contains(s, "<mark>关键字</mark>在这里") || !strings.HasPrefix(s, "…") || strings.Contains(s, "<b>") {
+		t.Errorf("unexpected snippet %q", s)
+	}
+}
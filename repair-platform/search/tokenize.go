@@ -0,0 +1,84 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK 判断是否为中日韩文字，这些文字之间没有空格，按二元组切分
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWordRune 判断是否为拼音文字单词的组成部分
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// Tokenize 将文本切分为检索词：拼音文字按单词小写，连续的中日韩文字按相邻两字切分（单字时保留单字）
+// 例如 "Steam 解锁游戏" 切分为 steam、解锁、锁游、游戏
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case isWordRune(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// terms 返回查询中用于高亮的原始片段：拼音文字单词和连续的中日韩文字，均为小写
+func terms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !isCJK(r) && !isWordRune(r)
+	})
+	var result []string
+	for _, f := range fields {
+		// 中日韩文字和拼音文字混写时分开，例如 "steam解锁"
+		var cur []rune
+		var curCJK bool
+		for _, r := range f {
+			if len(cur) > 0 && isCJK(r) != curCJK {
+				result = append(result, string(cur))
+				cur = cur[:0]
+			}
+			cur = append(cur, r)
+			curCJK = isCJK(r)
+		}
+		if len(cur) > 0 {
+			result = append(result, string(cur))
+		}
+	}
+	return result
+}
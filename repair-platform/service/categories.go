@@ -0,0 +1,270 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+)
+
+// 分类移动失败的原因
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+	ErrCategoryIntoSelf = errors.New("cannot move a category into itself")
+)
+
+// CategoryNode 分类树中的节点
+type CategoryNode struct {
+	Path        string          `json:"path"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Sort        int             `json:"sort"`
+	PostCount   int64           `json:"post_count"` // 直接属于该分类的文章数
+	Total       int64           `json:"total"`      // 包含子分类的文章数
+	Children    []*CategoryNode `json:"children"`
+}
+
+// Find 在以 nodes 为根的树中查找路径为 p 的节点
+func Find(nodes []*CategoryNode, p string) *CategoryNode {
+	for _, n := range nodes {
+		if n.Path == p {
+			return n
+		}
+		if models.InPath(p, n.Path) {
+			return Find(n.Children, p)
+		}
+	}
+	return nil
+}
+
+// CategoryTree 合并分类记录、文章所在的文件夹和 extra 中的路径，生成按 Sort、显示名排序的分类树
+// publishedOnly 为 true 时只统计已发布的文章，没有记录且没有已发布文章的文件夹不出现在树中
+func CategoryTree(db *gorm.DB, extra []string, publishedOnly bool) ([]*CategoryNode, error) {
+	var categories []models.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	var counts []struct {
+		Folder string
+		Count  int64
+	}
+	query := db.Model(&models.Post{}).Select("folder, COUNT(*) AS count").Where("folder <> ''").Group("folder")
+	if publishedOnly {
+		query = query.Where("status = ?", models.PostPublished)
+	}
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	nodes := make(map[string]*CategoryNode)
+	var roots []*CategoryNode
+	var ensure func(p string) *CategoryNode
+	ensure = func(p string) *CategoryNode {
+		if n, ok := nodes[p]; ok {
+			return n
+		}
+		n := &CategoryNode{Path: p, Name: models.BaseName(p), Children: []*CategoryNode{}}
+		nodes[p] = n
+		if parent := models.ParentPath(p); parent != "" {
+			pn := ensure(parent)
+			pn.Children = append(pn.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+		return n
+	}
+
+	for _, c := range categories {
+		n := ensure(c.Path)
+		n.Name, n.Description, n.Sort = c.DisplayName(), c.Description, c.Sort
+	}
+	for _, c := range counts {
+		ensure(c.Folder).PostCount = c.Count
+	}
+	for _, p := range extra {
+		ensure(p)
+	}
+
+	sortNodes(roots)
+	return roots, nil
+}
+
+// sortNodes 排序并计算每个节点包含子分类的文章数
+func sortNodes(nodes []*CategoryNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Sort != nodes[j].Sort {
+			return nodes[i].Sort < nodes[j].Sort
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, n := range nodes {
+		sortNodes(n.Children)
+		n.Total = n.PostCount
+		for _, child := range n.Children {
+			n.Total += child.Total
+		}
+	}
+}
+
+// SaveCategory 按路径创建或更新分类的显示信息；路径重新启用后不再重定向
+func SaveCategory(db *gorm.DB, category *models.Category) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Category
+		err := tx.Where("path = ?", category.Path).First(&existing).Error
+		switch {
+		case err == nil:
+			category.ID, category.CreatedAt = existing.ID, existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to load category: %w", err)
+		}
+		if err := tx.Save(category).Error; err != nil {
+			return fmt.Errorf("failed to save category: %w", err)
+		}
+		if err := tx.Where("old_path = ?", category.Path).Delete(&models.CategoryRedirect{}).Error; err != nil {
+			return fmt.Errorf("failed to delete redirect: %w", err)
+		}
+		return nil
+	})
+}
+
+// ResolveCategoryRedirect 返回已移动分类 p 的新路径：按最长的已移动上级路径替换前缀，没有重定向时返回 false
+func ResolveCategoryRedirect(db *gorm.DB, p string) (string, bool, error) {
+	var prefixes []string
+	for prefix := p; prefix != ""; prefix = models.ParentPath(prefix) {
+		prefixes = append(prefixes, prefix)
+	}
+	var redirects []models.CategoryRedirect
+	if err := db.Where("old_path IN ?", prefixes).Find(&redirects).Error; err != nil {
+		return "", false, fmt.Errorf("failed to load redirects: %w", err)
+	}
+	var best *models.CategoryRedirect
+	for i := range redirects {
+		if best == nil || len(redirects[i].OldPath) > len(best.OldPath) {
+			best = &redirects[i]
+		}
+	}
+	if best == nil {
+		return "", false, nil
+	}
+	return best.NewPath + p[len(best.OldPath):], true, nil
+}
+
+// MoveResult 分类移动的结果
+type MoveResult struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Posts int    `json:"posts"` // 随分类移动的文章数，包含子分类
+}
+
+// MoveCategory 将分类及其子分类从 from 移动到 to（同级改名也是移动），同时移动 files 中的目录
+// 文章的 Folder 和文件路径随之更新，slug 不变；旧路径保留重定向。目录移动失败时数据库修改一并回滚
+func MoveCategory(db *gorm.DB, files *storage.Local, from, to string) (MoveResult, error) {
+	result := MoveResult{From: from, To: to}
+	if from == to {
+		return result, nil
+	}
+	if models.InPath(to, from) {
+		return result, ErrCategoryIntoSelf
+	}
+	if ok, err := categoryExists(db, files, from); err != nil || !ok {
+		if err == nil {
+			err = ErrCategoryNotFound
+		}
+		return result, err
+	}
+	if ok, err := categoryExists(db, files, to); err != nil || ok {
+		if err == nil {
+			err = ErrCategoryExists
+		}
+		return result, err
+	}
+	rebase := func(p string) string { return to + p[len(from):] }
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var categories []models.Category
+		if err := inPathQuery(tx, "path", from).Find(&categories).Error; err != nil {
+			return fmt.Errorf("failed to load categories: %w", err)
+		}
+		for _, c := range categories {
+			if err := tx.Model(&c).UpdateColumn("path", rebase(c.Path)).Error; err != nil {
+				return fmt.Errorf("failed to move category %s: %w", c.Path, err)
+			}
+		}
+
+		// 已删除的文章也一并移动，恢复后文件路径仍然有效
+		var posts []models.Post
+		if err := inPathQuery(tx.Unscoped(), "folder", from).Select("id", "folder", "source_path").Find(&posts).Error; err != nil {
+			return fmt.Errorf("failed to load posts: %w", err)
+		}
+		for _, p := range posts {
+			updates := map[string]interface{}{"folder": rebase(p.Folder)}
+			if strings.HasPrefix(p.SourcePath, from+"/") {
+				updates["source_path"] = rebase(p.SourcePath)
+			}
+			if err := tx.Unscoped().Model(&p).UpdateColumns(updates).Error; err != nil {
+				return fmt.Errorf("failed to move post %d: %w", p.ID, err)
+			}
+		}
+		result.Posts = len(posts)
+
+		// 指向旧路径的重定向改为指向新路径，避免多次跳转；新路径上已有的重定向失效
+		var redirects []models.CategoryRedirect
+		if err := inPathQuery(tx, "new_path", from).Find(&redirects).Error; err != nil {
+			return fmt.Errorf("failed to load redirects: %w", err)
+		}
+		for _, r := range redirects {
+			if err := tx.Model(&r).UpdateColumn("new_path", rebase(r.NewPath)).Error; err != nil {
+				return fmt.Errorf("failed to update redirect %s: %w", r.OldPath, err)
+			}
+		}
+		if err := inPathQuery(tx, "old_path", to).Delete(&models.CategoryRedirect{}).Error; err != nil {
+			return fmt.Errorf("failed to delete redirects: %w", err)
+		}
+		if err := tx.Where("old_path = ?", from).Delete(&models.CategoryRedirect{}).Error; err != nil {
+			return fmt.Errorf("failed to delete redirects: %w", err)
+		}
+		if err := tx.Create(&models.CategoryRedirect{OldPath: from, NewPath: to}).Error; err != nil {
+			return fmt.Errorf("failed to create redirect: %w", err)
+		}
+
+		if exists, err := files.Exists(from); err != nil || !exists {
+			return err
+		}
+		return files.Rename(from, to)
+	})
+	return result, err
+}
+
+// categoryExists 判断分类是否存在：有分类记录、有文章（包括已删除的）或目录存在，子分类同样计入
+func categoryExists(db *gorm.DB, files *storage.Local, p string) (bool, error) {
+	if exists, err := files.Exists(p); err != nil || exists {
+		return exists, err
+	}
+	var count int64
+	if err := inPathQuery(db.Model(&models.Category{}), "path", p).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check category: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := inPathQuery(db.Unscoped().Model(&models.Post{}), "folder", p).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check posts: %w", err)
+	}
+	return count > 0, nil
+}
+
+// inPathQuery 筛选 column 为路径 p 本身或其子路径的记录
+func inPathQuery(db *gorm.DB, column, p string) *gorm.DB {
+	return db.Where(column+" = ? OR "+column+" LIKE ? ESCAPE '!'", p, EscapeLike(p)+"/%")
+}
+
+// EscapeLike 转义 LIKE 模式中的通配符，转义字符使用各数据库含义一致的 '!'
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
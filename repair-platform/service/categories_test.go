@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"repair-platform/models"
+	"repair-platform/storage"
+)
+
+func TestMoveCategory(t *testing.T) {
+	db := openTestDB(t)
+	base := t.TempDir()
+	files := storage.NewLocal(base, nil)
+	if err := os.MkdirAll(filepath.Join(base, "guides", "phone"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "guides", "phone", "screen.md"), []byte("body"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	posts := []models.Post{
+		{Title: "screen", Slug: "screen", Folder: "guides/phone", SourcePath: "guides/phone/screen.md", Status: models.PostPublished},
+		{Title: "intro", Slug: "intro", Folder: "guides", Status: models.PostDraft},
+		{Title: "other", Slug: "other", Folder: "guidesx", Status: models.PostPublished},
+	}
+	if err := db.Create(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCategory(db, &models.Category{Path: "guides/phone", Name: "手机", Sort: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := CategoryTree(db, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := Find(tree, "guides"); node == nil || node.PostCount != 0 || node.Total != 1 || len(node.Children) != 1 || node.Children[0].Name != "手机" {
+		t.Fatalf("unexpected tree: %+v", node)
+	}
+
+	if _, err := MoveCategory(db, files, "guides", "guides/old"); !errors.Is(err, ErrCategoryIntoSelf) {
+		t.Fatalf("expected ErrCategoryIntoSelf, got %v", err)
+	}
+	if _, err := MoveCategory(db, files, "guides", "guidesx"); !errors.Is(err, ErrCategoryExists) {
+		t.Fatalf("expected ErrCategoryExists, got %v", err)
+	}
+	if _, err := MoveCategory(db, files, "missing", "other"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+
+	result, err := MoveCategory(db, files, "guides", "help/repair")
+	if err != nil || result.Posts != 2 {
+		t.Fatalf("move: %+v %v", result, err)
+	}
+	var moved models.Post
+	db.First(&moved, posts[0].ID)
+	if moved.Folder != "help/repair/phone" || moved.SourcePath != "help/repair/phone/screen.md" || moved.Slug != "screen" {
+		t.Fatalf("unexpected moved post: %+v", moved)
+	}
+	if _, err := os.Stat(filepath.Join(base, "help", "repair", "phone", "screen.md")); err != nil {
+		t.Fatalf("expected file to move: %v", err)
+	}
+	var other models.Post
+	db.First(&other, posts[2].ID)
+	if other.Folder != "guidesx" {
+		t.Fatalf("sibling with common prefix should not move: %+v", other)
+	}
+
+	// 旧路径及其子路径重定向到新路径，再次移动后不会产生多次跳转
+	if _, err := MoveCategory(db, files, "help/repair", "repair"); err != nil {
+		t.Fatal(err)
+	}
+	for old, want := range map[string]string{"guides": "repair", "guides/phone": "repair/phone", "help/repair": "repair"} {
+		if got, ok, err := ResolveCategoryRedirect(db, old); err != nil || !ok || got != want {
+			t.Errorf("redirect %s: got %q %v %v, want %q", old, got, ok, err, want)
+		}
+	}
+	if _, ok, _ := ResolveCategoryRedirect(db, "guidesx"); ok {
+		t.Error("guidesx should not be redirected")
+	}
+
+	// 重新启用旧路径后不再重定向
+	if err := SaveCategory(db, &models.Category{Path: "guides"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := ResolveCategoryRedirect(db, "guides"); ok {
+		t.Error("reclaimed path should not be redirected")
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+)
+
+// PurgeExpiredTokens 删除已过期的验证码和密码重置令牌
+func PurgeExpiredTokens(ctx context.Context, db *gorm.DB) error {
+	return models.DeleteExpiredTokens(db.WithContext(ctx))
+}
+
+// SweepOrphanUploads 删除上传存储根目录（不含子目录）中未被任何报修请求引用的文件，报修图片的派生尺寸随原图保留
+// 修改时间在 grace 之内的文件可能仍在写入或等待入库，不会被删除；原图已不被引用的图片记录一并删除
+func SweepOrphanUploads(ctx context.Context, db *gorm.DB, files storage.Storage, grace time.Duration) (int, error) {
+	objects, err := files.List(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list uploads: %w", err)
+	}
+
+	// 软删除的报修请求在被彻底清理前仍然保留其文件
+	var urls []string
+	if err := db.WithContext(ctx).Unscoped().Model(&models.RepairRequest{}).
+		Where("image_url <> ''").Pluck("image_url", &urls).Error; err != nil {
+		return 0, fmt.Errorf("failed to load referenced uploads: %w", err)
+	}
+	referenced := make(map[string]struct{}, len(urls))
+	for _, u := range urls {
+		referenced[models.AttachmentKey(u)] = struct{}{}
+	}
+	var images []models.Image
+	if err := db.WithContext(ctx).Where("source = ?", models.ImageSourceRepair).Find(&images).Error; err != nil {
+		return 0, fmt.Errorf("failed to load repair images: %w", err)
+	}
+	cutoff := time.Now().Add(-grace)
+	var orphanImages []uint
+	for _, img := range images {
+		if _, ok := referenced[img.ObjectKey]; ok {
+			for _, v := range img.Variants {
+				referenced[v.Key] = struct{}{}
+			}
+		} else if img.CreatedAt.Before(cutoff) {
+			orphanImages = append(orphanImages, img.ID)
+		}
+	}
+
+	removed := 0
+	for _, obj := range objects {
+		if strings.Contains(obj.Key, "/") || strings.HasPrefix(obj.Key, ".") {
+			continue
+		}
+		if _, ok := referenced[obj.Key]; ok || obj.ModTime.After(cutoff) {
+			continue
+		}
+		if err := files.Delete(ctx, obj.Key); err != nil {
+			return removed, fmt.Errorf("failed to remove orphan upload %s: %w", obj.Key, err)
+		}
+		removed++
+	}
+	if len(orphanImages) > 0 {
+		if err := db.WithContext(ctx).Delete(&models.Image{}, orphanImages).Error; err != nil {
+			return removed, fmt.Errorf("failed to remove orphan image records: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// PurgeSoftDeleted 彻底删除软删除时间早于保留期的记录，返回删除的行数
+// 软删除期间保留文章的 Markdown 源文件以便恢复，文章彻底删除后源文件从 markdown 中删除，避免再次被导入
+func PurgeSoftDeleted(ctx context.Context, db *gorm.DB, markdown storage.Storage, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	expired := func() *gorm.DB {
+		return db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	}
+
+	// 仍被未删除的文章使用的文件保留
+	live := db.Model(&models.Post{}).Where("source_path <> ''").Select("source_path")
+	var sources []string
+	if err := expired().Model(&models.Post{}).Where("source_path <> '' AND source_path NOT IN (?)", live).
+		Pluck("source_path", &sources).Error; err != nil {
+		return 0, fmt.Errorf("failed to load purged post sources: %w", err)
+	}
+
+	var total int64
+	for _, model := range []interface{}{&models.Feedback{}, &models.RepairRequest{}, &models.Post{}, &models.User{}} {
+		result := expired().Delete(model)
+		if result.Error != nil {
+			return total, fmt.Errorf("failed to purge soft-deleted rows: %w", result.Error)
+		}
+		total += result.RowsAffected
+	}
+
+	for _, source := range sources {
+		if err := markdown.Delete(ctx, source); err != nil {
+			return total, fmt.Errorf("failed to remove source of purged post %s: %w", source, err)
+		}
+	}
+
+	// 文章彻底删除后其版本历史也一并删除
+	result := db.WithContext(ctx).
+		Where("post_id NOT IN (?)", db.Unscoped().Model(&models.Post{}).Select("id")).
+		Delete(&models.PostRevision{})
+	if result.Error != nil {
+		return total, fmt.Errorf("failed to purge post revisions: %w", result.Error)
+	}
+	return total + result.RowsAffected, nil
+}
@@ -5,45 +5,66 @@ import (
 	"fmt"
 	"math/rand"
 	"net/smtp"
+	"repair-platform/apperror"
+	"repair-platform/config"
 	"repair-platform/database"
+	"repair-platform/i18n"
+	"repair-platform/metrics"
+	"repair-platform/tracing"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9" // 导入 redis 包
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// redisCodeTTL 存放在 Redis 中的验证码有效期
+const redisCodeTTL = 5 * time.Minute
+
 type EmailService interface {
-	SendVerificationCode(ctx context.Context, to string) error
+	SendVerificationCode(ctx context.Context, to string, locale i18n.Locale) error
 	VerifyVerificationCode(ctx context.Context, email string, code string) bool
+	SendMail(to string, subject string, body string) error
 }
 
 type emailService struct {
+	cfg    config.SMTPConfig
 	logger *zap.SugaredLogger
 }
 
 // NewEmailService 创建一个新的EmailService实例
-func NewEmailService(logger *zap.SugaredLogger) EmailService {
+func NewEmailService(cfg config.SMTPConfig, logger *zap.SugaredLogger) EmailService {
 	return &emailService{
+		cfg:    cfg,
 		logger: logger,
 	}
 }
 
-// SendVerificationCode 发送验证码到用户的邮箱
-func (e *emailService) SendVerificationCode(ctx context.Context, to string) error {
+// SendVerificationCode 按收件人的语言发送验证码到用户的邮箱，未配置 Redis 时返回 apperror.ErrServiceUnavailable
+func (e *emailService) SendVerificationCode(ctx context.Context, to string, locale i18n.Locale) error {
+	client := database.GetRedisClient()
+	if client == nil {
+		return apperror.ErrServiceUnavailable.Wrap(database.ErrRedisNotConfigured)
+	}
 	code, err := generateVerificationCode()
 	if err != nil {
 		e.logger.Errorf("Failed to generate verification code: %v", err)
 		return fmt.Errorf("failed to generate verification code: %v", err)
 	}
 
-	// 调用发送邮件的内部函数
-	if err := e.sendVerificationCode(to, code); err != nil {
+	// 发送验证码邮件
+	subject := i18n.T(locale, "mail.verification_code.subject")
+	body := i18n.T(locale, "mail.verification_code.body", code, int(redisCodeTTL/time.Minute))
+	if err := e.SendMail(to, subject, body); err != nil {
 		e.logger.Errorf("Failed to send verification code: %v", err)
 		return err
 	}
 
 	// 将验证码存储到 Redis 中，设置5分钟过期
-	err = database.GetRedisClient().Set(ctx, to, code, 5*time.Minute).Err()
+	err = client.Set(ctx, to, code, redisCodeTTL).Err()
 	if err != nil {
 		e.logger.Errorf("Failed to store verification code in Redis: %v", err)
 		return fmt.Errorf("failed to store verification code in Redis: %v", err)
@@ -52,21 +73,32 @@ func (e *emailService) SendVerificationCode(ctx context.Context, to string) erro
 	return nil
 }
 
-// sendVerificationCode 内部函数，用于发送邮件
-func (e *emailService) sendVerificationCode(to string, code string) error {
-	from := "xloudmaxx@gmail.com"
-	password := "mbbf hrde wlpk bphe"
-
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
+// SendMail 通过配置的 SMTP 服务器发送一封纯文本邮件
+func (e *emailService) SendMail(to string, subject string, body string) error {
+	if e.cfg.Host == "" {
+		return fmt.Errorf("smtp is not configured")
+	}
 
 	// 构建邮件内容
-	msg := fmt.Sprintf("From: %s\nTo: %s\nSubject: Verification Code\n\nYour verification code is: %s", from, to, code)
+	msg := fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\n\n%s", e.cfg.From, to, subject, body)
 
-	// 发送邮件
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{to}, []byte(msg))
+	// 未单独配置用户名时使用发件地址登录
+	username := e.cfg.Username
+	if username == "" {
+		username = e.cfg.From
+	}
+
+	// 发送邮件，邮件多由后台队列发出，span 没有父节点
+	_, span := tracing.Start(context.Background(), "smtp.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.ServerAddress(e.cfg.Host), semconv.ServerPort(e.cfg.Port)))
+	defer span.End()
+
+	auth := smtp.PlainAuth("", username, e.cfg.Password, e.cfg.Host)
+	err := smtp.SendMail(e.cfg.Host+":"+strconv.Itoa(e.cfg.Port), auth, e.cfg.From, []string{to}, []byte(msg))
+	metrics.ObserveMail(err)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		e.logger.Errorf("Failed to send email: %v", err)
 		return fmt.Errorf("failed to send email: %v", err)
 	}
@@ -81,9 +113,14 @@ func generateVerificationCode() (string, error) {
 	return code, nil
 }
 
-// VerifyVerificationCode 验证用户输入的验证码是否正确
+// VerifyVerificationCode 验证用户输入的验证码是否正确，未配置 Redis 时总是返回 false
 func (e *emailService) VerifyVerificationCode(ctx context.Context, email string, code string) bool {
-	storedCode, err := database.GetRedisClient().Get(ctx, email).Result()
+	client := database.GetRedisClient()
+	if client == nil {
+		e.logger.Errorf("Cannot verify code for %s: %v", email, database.ErrRedisNotConfigured)
+		return false
+	}
+	storedCode, err := client.Get(ctx, email).Result()
 	if err == redis.Nil {
 		e.logger.Warnf("Verification code not found for email: %s", email)
 		return false
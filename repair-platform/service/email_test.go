@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"repair-platform/apperror"
+	"repair-platform/config"
+	"repair-platform/i18n"
+
+	"go.uber.org/zap"
+)
+
+func TestVerificationCodeWithoutRedis(t *testing.T) {
+	svc := NewEmailService(config.SMTPConfig{}, zap.NewNop().Sugar())
+
+	err := svc.SendVerificationCode(context.Background(), "user@example.com", i18n.Default)
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperror.CodeServiceUnavailable {
+		t.Fatalf("expected service unavailable, got %v", err)
+	}
+	if svc.VerifyVerificationCode(context.Background(), "user@example.com", "123456") {
+		t.Fatal("expected verification to fail without Redis")
+	}
+}
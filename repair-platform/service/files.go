@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+)
+
+// 文件和文件夹操作失败的原因
+var (
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+	ErrFileNotFound   = errors.New("file not found")
+	ErrFileExists     = errors.New("file already exists")
+)
+
+// DeleteFolderResult 删除文件夹的结果
+type DeleteFolderResult struct {
+	Folder string `json:"folder"`
+	Posts  []uint `json:"posts"` // 随文件夹删除的文章 ID
+}
+
+// DeleteFolder 删除 files 中的文件夹 folder 及其分类记录
+// 文件夹中有文件、子文件夹或文章时，只有 recursive 为 true 才会删除全部内容，文章软删除；目录删除失败时数据库修改一并回滚
+func DeleteFolder(db *gorm.DB, files *storage.Local, folder string, recursive bool) (DeleteFolderResult, error) {
+	result := DeleteFolderResult{Folder: folder, Posts: []uint{}}
+	if ok, err := categoryExists(db, files, folder); err != nil || !ok {
+		if err == nil {
+			err = ErrCategoryNotFound
+		}
+		return result, err
+	}
+
+	entries, err := files.ReadDir(folder)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return result, fmt.Errorf("failed to read folder: %w", err)
+	}
+	if err := inPathQuery(db.Model(&models.Post{}), "folder", folder).Pluck("id", &result.Posts).Error; err != nil {
+		return result, fmt.Errorf("failed to load posts: %w", err)
+	}
+	if !recursive && (len(entries) > 0 || len(result.Posts) > 0) {
+		return result, ErrFolderNotEmpty
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(result.Posts) > 0 {
+			if err := tx.Delete(&models.Post{}, result.Posts).Error; err != nil {
+				return fmt.Errorf("failed to delete posts: %w", err)
+			}
+		}
+		if err := inPathQuery(tx, "path", folder).Delete(&models.Category{}).Error; err != nil {
+			return fmt.Errorf("failed to delete categories: %w", err)
+		}
+		// 指向被删除文件夹的重定向不再有效
+		if err := inPathQuery(tx, "new_path", folder).Delete(&models.CategoryRedirect{}).Error; err != nil {
+			return fmt.Errorf("failed to delete redirects: %w", err)
+		}
+		if err := files.RemoveAll(folder); err != nil {
+			return fmt.Errorf("failed to delete folder: %w", err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// MoveMarkdownFile 将 files 中的 Markdown 文件从 from 移动到 to（相对路径，以 / 分隔），同一文件夹内即为改名
+// 对应文章的文件路径和分类随之更新，slug 不变；文章不存在时返回 nil
+func MoveMarkdownFile(db *gorm.DB, files *storage.Local, from, to string) (*models.Post, error) {
+	if _, err := files.Stat(db.Statement.Context, from); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	if from == to {
+		return nil, nil
+	}
+	if exists, err := files.Exists(to); err != nil || exists {
+		if err == nil {
+			err = ErrFileExists
+		}
+		return nil, err
+	}
+
+	var post *models.Post
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var p models.Post
+		err := tx.Where("source_path = ?", from).First(&p).Error
+		switch {
+		case err == nil:
+			p.SourcePath, p.Folder = to, path.Dir(to)
+			if err := tx.Model(&p).UpdateColumns(map[string]interface{}{"source_path": p.SourcePath, "folder": p.Folder}).Error; err != nil {
+				return fmt.Errorf("failed to update post: %w", err)
+			}
+			post = &p
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to load post: %w", err)
+		}
+
+		return files.Rename(from, to)
+	})
+	return post, err
+}
+
+// DeleteMarkdownFile 删除 files 中的 Markdown 文件，对应的文章一并软删除；返回被删除的文章，没有时为 nil
+func DeleteMarkdownFile(db *gorm.DB, files storage.Storage, p string) (*models.Post, error) {
+	ctx := db.Statement.Context
+	if _, err := files.Stat(ctx, p); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	var post *models.Post
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var found models.Post
+		err := tx.Where("source_path = ?", p).First(&found).Error
+		switch {
+		case err == nil:
+			if err := tx.Delete(&found).Error; err != nil {
+				return fmt.Errorf("failed to delete post: %w", err)
+			}
+			post = &found
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to load post: %w", err)
+		}
+		return files.Delete(ctx, p)
+	})
+	return post, err
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"repair-platform/models"
+	"repair-platform/storage"
+)
+
+func TestFileOperations(t *testing.T) {
+	db := openTestDB(t)
+	base := t.TempDir()
+	files := storage.NewLocal(base, nil)
+	write := func(name string) {
+		p := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("body"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("docs/a.md")
+	write("docs/b.md")
+	post := models.Post{Title: "a", Slug: "a", Folder: "docs", SourcePath: "docs/a.md", Status: models.PostPublished}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MoveMarkdownFile(db, files, "docs/a.md", "docs/b.md"); !errors.Is(err, ErrFileExists) {
+		t.Fatalf("expected ErrFileExists, got %v", err)
+	}
+	if _, err := MoveMarkdownFile(db, files, "docs/missing.md", "docs/c.md"); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("expected ErrFileNotFound, got %v", err)
+	}
+	moved, err := MoveMarkdownFile(db, files, "docs/a.md", "archive/2024/a.md")
+	if err != nil || moved == nil || moved.Folder != "archive/2024" || moved.SourcePath != "archive/2024/a.md" {
+		t.Fatalf("move: %+v %v", moved, err)
+	}
+
+	// 非空文件夹只能递归删除，文章随之删除
+	if _, err := DeleteFolder(db, files, "archive", false); !errors.Is(err, ErrFolderNotEmpty) {
+		t.Fatalf("expected ErrFolderNotEmpty, got %v", err)
+	}
+	result, err := DeleteFolder(db, files, "archive", true)
+	if err != nil || len(result.Posts) != 1 || result.Posts[0] != post.ID {
+		t.Fatalf("delete folder: %+v %v", result, err)
+	}
+	if err := db.First(&models.Post{}, post.ID).Error; err == nil {
+		t.Fatal("expected post to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(base, "archive")); !os.IsNotExist(err) {
+		t.Fatalf("expected folder to be removed, got %v", err)
+	}
+
+	deleted, err := DeleteMarkdownFile(db, files, "docs/b.md")
+	if err != nil || deleted != nil {
+		t.Fatalf("delete file without post: %+v %v", deleted, err)
+	}
+	if _, err := DeleteFolder(db, files, "docs", false); err != nil {
+		t.Fatalf("empty folder should be deleted: %v", err)
+	}
+	if _, err := DeleteFolder(db, files, "docs", false); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"repair-platform/imageproc"
+	"repair-platform/models"
+	"repair-platform/storage"
+)
+
+// StoreImage 将处理后的图片写入 files：原图的 key 为 stem 加扩展名，派生尺寸为 stem_名称 加扩展名
+// 返回的记录尚未入库；任一对象写入失败时删除已写入的对象
+func StoreImage(ctx context.Context, files storage.Storage, stem, source string, res *imageproc.Result) (*models.Image, error) {
+	o := res.Original
+	img := &models.Image{
+		ObjectKey:   stem + o.Ext,
+		Source:      source,
+		ContentType: o.ContentType,
+		Width:       o.Width,
+		Height:      o.Height,
+		Size:        int64(len(o.Data)),
+	}
+	if err := files.Put(ctx, img.ObjectKey, bytes.NewReader(o.Data), img.Size, o.ContentType); err != nil {
+		return nil, err
+	}
+	for _, v := range res.Variants {
+		variant := models.ImageVariant{
+			Name:        v.Name,
+			Key:         stem + "_" + v.Name + v.Ext,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        int64(len(v.Data)),
+		}
+		if err := files.Put(ctx, variant.Key, bytes.NewReader(v.Data), variant.Size, v.ContentType); err != nil {
+			_ = DeleteImageObjects(ctx, files, img)
+			return nil, err
+		}
+		img.Variants = append(img.Variants, variant)
+	}
+	return img, nil
+}
+
+// DeleteImageObjects 删除图片的原图和全部派生尺寸，返回遇到的全部错误
+func DeleteImageObjects(ctx context.Context, files storage.Storage, img *models.Image) error {
+	var errs []error
+	for _, key := range img.Keys() {
+		if err := files.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"repair-platform/imageproc"
+	"repair-platform/models"
+	"repair-platform/storage"
+)
+
+func TestStoreImageAndSweep(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	files := storage.NewMemory(nil)
+	res := &imageproc.Result{
+		Original: imageproc.Variant{Name: "original", Ext: ".jpg", ContentType: "image/jpeg", Width: 40, Height: 30, Data: []byte("jpeg")},
+		Variants: []imageproc.Variant{{Name: "thumbnail", Ext: ".webp", ContentType: "image/webp", Width: 4, Height: 3, Data: []byte("webp")}},
+	}
+
+	kept, err := StoreImage(ctx, files, "1", models.ImageSourceRepair, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.ObjectKey != "1.jpg" || len(kept.Variants) != 1 || kept.Variants[0].Key != "1_thumbnail.webp" || kept.Width != 40 {
+		t.Fatalf("unexpected image: %+v", kept)
+	}
+	orphan, err := StoreImage(ctx, files, "2", models.ImageSourceRepair, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.RepairRequest{Description: "x", ImageURL: kept.ObjectKey}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create([]*models.Image{kept, orphan}).Error; err != nil {
+		t.Fatal(err)
+	}
+	_ = files.Put(ctx, "3.pdf", strings.NewReader("pdf"), 3, "")
+
+	// 派生尺寸随被引用的原图保留，未被引用的原图、派生尺寸和图片记录一并删除
+	removed, err := SweepOrphanUploads(ctx, db, files, 0)
+	if err != nil || removed != 3 {
+		t.Fatalf("sweep: removed %d, %v", removed, err)
+	}
+	for _, key := range kept.Keys() {
+		if _, err := files.Stat(ctx, key); err != nil {
+			t.Errorf("expected %s to be kept: %v", key, err)
+		}
+	}
+	for _, key := range append(orphan.Keys(), "3.pdf") {
+		if _, err := files.Stat(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("expected %s to be removed: %v", key, err)
+		}
+	}
+	var images []models.Image
+	if err := db.Find(&images).Error; err != nil || len(images) != 1 || images[0].ID != kept.ID || len(images[0].Variants) != 1 {
+		t.Fatalf("image records: %+v %v", images, err)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrMailQueueFull 表示邮件队列已满
+var ErrMailQueueFull = errors.New("mail queue is full")
+
+// mailMessage 是队列中的一封待发送邮件
+type mailMessage struct {
+	to      string
+	subject string
+	body    string
+}
+
+// MailQueue 包装 EmailService，SendMail 只入队，由后台任务异步发送并在失败时重试
+type MailQueue struct {
+	EmailService
+	messages   chan mailMessage
+	maxRetries int
+	retryDelay time.Duration
+	logger     *zap.SugaredLogger
+}
+
+// NewMailQueue 创建邮件队列，size 为队列容量，maxRetries 为单封邮件的最大重试次数
+func NewMailQueue(sender EmailService, size int, maxRetries int, logger *zap.SugaredLogger) *MailQueue {
+	return &MailQueue{
+		EmailService: sender,
+		messages:     make(chan mailMessage, size),
+		maxRetries:   maxRetries,
+		retryDelay:   2 * time.Second,
+		logger:       logger,
+	}
+}
+
+// SendMail 将邮件放入队列，队列已满时立即返回 ErrMailQueueFull
+func (q *MailQueue) SendMail(to string, subject string, body string) error {
+	select {
+	case q.messages <- mailMessage{to: to, subject: subject, body: body}:
+		return nil
+	default:
+		q.logger.Errorf("邮件队列已满，丢弃发往 %s 的邮件", to)
+		return ErrMailQueueFull
+	}
+}
+
+// Run 持续发送队列中的邮件，ctx 取消后尽力发送剩余邮件（不再重试）后返回
+func (q *MailQueue) Run(ctx context.Context) error {
+	for {
+		select {
+		case msg := <-q.messages:
+			q.deliver(ctx, msg, q.maxRetries)
+		case <-ctx.Done():
+			q.drain()
+			return nil
+		}
+	}
+}
+
+// drain 发送退出时仍在队列中的邮件
+func (q *MailQueue) drain() {
+	for {
+		select {
+		case msg := <-q.messages:
+			q.deliver(context.Background(), msg, 0)
+		default:
+			return
+		}
+	}
+}
+
+// deliver 发送单封邮件，失败时按递增间隔重试
+func (q *MailQueue) deliver(ctx context.Context, msg mailMessage, retries int) {
+	for attempt := 0; ; attempt++ {
+		err := q.EmailService.SendMail(msg.to, msg.subject, msg.body)
+		if err == nil {
+			return
+		}
+		if attempt >= retries {
+			q.logger.Errorf("发往 %s 的邮件发送失败，已放弃: %v", msg.to, err)
+			return
+		}
+		q.logger.Warnf("发往 %s 的邮件发送失败，第 %d 次重试: %v", msg.to, attempt+1, err)
+
+		select {
+		case <-time.After(q.retryDelay * time.Duration(attempt+1)):
+		case <-ctx.Done():
+			q.logger.Errorf("服务退出，发往 %s 的邮件未能发送", msg.to)
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"repair-platform/frontmatter"
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+)
+
+// summaryLength 自动生成摘要时保留的最大字符数
+const summaryLength = 200
+
+// ImportResult 目录导入的统计结果
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"` // 之前已导入过的文件
+}
+
+// ImportMarkdownTree 将 files 中各级文件夹里的 *.md 导入为文章，相对目录即文章的分类，根目录下的文件不导入
+// 元数据来自 front matter，缺省时文件名作为标题、文件修改时间作为发布时间；按相对路径判断是否已导入，因此可重复执行
+func ImportMarkdownTree(ctx context.Context, db *gorm.DB, files *storage.Local, author models.User) (ImportResult, error) {
+	var result ImportResult
+	db = db.WithContext(ctx)
+
+	err := files.Walk(ctx, func(key string, entry fs.DirEntry) error {
+		if entry.IsDir() || path.Ext(key) != ".md" || path.Dir(key) == "." {
+			return nil
+		}
+		imported, err := importMarkdownFile(ctx, db, files, key, author)
+		if err != nil {
+			return err
+		}
+		if imported {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// importMarkdownFile 导入单个文件，已导入过时返回 false
+func importMarkdownFile(ctx context.Context, db *gorm.DB, files *storage.Local, source string, author models.User) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&models.Post{}).Where("source_path = ?", source).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check post %s: %w", source, err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	r, info, err := files.Get(ctx, source)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	content, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	folder, filename := path.Split(source)
+	post, err := NewMarkdownPost(db, strings.TrimSuffix(folder, "/"), filename, content, author, info.ModTime)
+	if err != nil {
+		return false, fmt.Errorf("failed to import %s: %w", source, err)
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		_, err := RecordRevision(tx, post, author, 0)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to import %s: %w", source, err)
+	}
+	return true, nil
+}
+
+// NewMarkdownPost 根据 Markdown 文件生成尚未保存的文章，front matter 中的字段优先
+// 没有 front matter 时以文件名作为标题、modTime 作为发布时间；date 在将来时定时发布；front matter 不合法时返回 frontmatter 包的错误
+func NewMarkdownPost(db *gorm.DB, folder, filename string, content []byte, author models.User, modTime time.Time) (*models.Post, error) {
+	meta, body, err := frontmatter.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		meta = &frontmatter.Meta{}
+	}
+
+	post := &models.Post{
+		Title:      meta.Title,
+		AuthorID:   author.ID,
+		Author:     meta.Author,
+		Folder:     folder,
+		Tags:       meta.Tags,
+		Summary:    meta.Summary,
+		Cover:      meta.Cover,
+		Status:     models.PostDraft,
+		Body:       string(body),
+		SourcePath: path.Join(folder, filename),
+	}
+	if post.Title == "" {
+		post.Title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	if post.Author == "" {
+		post.Author = author.Username
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+	if post.Summary == "" {
+		post.Summary = Summarize(post.Body)
+	}
+	switch {
+	case meta.Draft:
+	case meta.Date != nil && meta.Date.After(time.Now()):
+		// 日期在将来的文章到时由定时任务发布
+		post.Schedule(*meta.Date)
+	default:
+		publishedAt := modTime
+		if meta.Date != nil {
+			publishedAt = *meta.Date
+		}
+		post.PublishedAt = &publishedAt
+		post.SetStatus(models.PostPublished)
+	}
+
+	post.Slug, err = models.UniqueSlug(db, models.Slugify(post.Title), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate slug: %w", err)
+	}
+	return post, nil
+}
+
+// PublishDuePosts 发布定时发布时间不晚于 now 的文章，返回已发布的文章
+func PublishDuePosts(ctx context.Context, db *gorm.DB, now time.Time) ([]models.Post, error) {
+	db = db.WithContext(ctx)
+
+	var posts []models.Post
+	if err := db.Where("status = ? AND publish_at <= ?", models.PostScheduled, now).Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load scheduled posts: %w", err)
+	}
+	// 只返回本次实际发布的文章，并发执行或期间被撤回的文章更新不到任何行
+	published := posts[:0]
+	for _, post := range posts {
+		post.PublishScheduled()
+		// 只在仍处于定时状态时更新，避免覆盖期间被管理员撤回的修改
+		result := db.Model(&models.Post{}).
+			Where("id = ? AND status = ?", post.ID, models.PostScheduled).
+			Updates(map[string]interface{}{
+				"status":       post.Status,
+				"published_at": post.PublishedAt,
+				"publish_at":   nil,
+			})
+		if result.Error != nil {
+			return published, fmt.Errorf("failed to publish post %d: %w", post.ID, result.Error)
+		}
+		if result.RowsAffected == 1 {
+			published = append(published, post)
+		}
+	}
+	return published, nil
+}
+
+// Summarize 取 Markdown 正文中第一个普通段落作为摘要，跳过标题、代码块、引用和图片
+func Summarize(body string) string {
+	inCode := false
+	var para []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if line == "" {
+			if len(para) > 0 {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">") || strings.HasPrefix(line, "![") ||
+			strings.HasPrefix(line, "---") || strings.HasPrefix(line, "|") {
+			if len(para) > 0 {
+				break
+			}
+			continue
+		}
+		para = append(para, line)
+	}
+
+	summary := []rune(strings.Join(para, " "))
+	if len(summary) > summaryLength {
+		return string(summary[:summaryLength]) + "…"
+	}
+	return string(summary)
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"repair-platform/migrations"
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if _, err := migrations.Up(db, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestImportMarkdownTree(t *testing.T) {
+	db := openTestDB(t)
+
+	base := t.TempDir()
+	contents := map[string]string{
+		"笔记/Hello World.md":   "# Hello\n\n```go\ncode\n```\n\nFirst paragraph\ncontinues here.\n\nSecond.",
+		"笔记/hello-world.md":   "same slug",
+		"笔记/ignored.txt":      "not markdown",
+		"misc/notes.md":       "",
+		"top-level-file.md":   "files outside folders are ignored",
+		"misc/nested/deep.md": "nested folders are categories too",
+	}
+	for name, content := range contents {
+		path := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := storage.NewLocal(base, nil)
+	author := models.User{ID: 7, Username: "admin"}
+	result, err := ImportMarkdownTree(context.Background(), db, files, author)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Imported != 4 || result.Skipped != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	var nested models.Post
+	if err := db.Where("source_path = ?", "misc/nested/deep.md").First(&nested).Error; err != nil || nested.Folder != "misc/nested" {
+		t.Fatalf("expected nested file in folder misc/nested, got %+v (%v)", nested, err)
+	}
+
+	var post models.Post
+	if err := db.Where("source_path = ?", "笔记/Hello World.md").First(&post).Error; err != nil {
+		t.Fatalf("load post: %v", err)
+	}
+	if post.Title != "Hello World" || post.Folder != "笔记" || post.Author != "admin" || post.AuthorID != 7 ||
+		post.Status != models.PostPublished || post.PublishedAt == nil {
+		t.Errorf("unexpected post %+v", post)
+	}
+	if post.Summary != "First paragraph continues here." {
+		t.Errorf("unexpected summary %q", post.Summary)
+	}
+
+	var slugs []string
+	db.Model(&models.Post{}).Where("folder = ?", "笔记").Order("slug").Pluck("slug", &slugs)
+	if len(slugs) != 2 || slugs[0] != "hello-world" || slugs[1] != "hello-world-2" {
+		t.Errorf("expected de-duplicated slugs, got %v", slugs)
+	}
+
+	// 重复执行时跳过已导入的文件
+	result, err = ImportMarkdownTree(context.Background(), db, files, author)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 4 {
+		t.Fatalf("expected all files to be skipped, got %+v", result)
+	}
+}
+
+func TestPurgedPostIsNotReimported(t *testing.T) {
+	db := openTestDB(t)
+	base := t.TempDir()
+	files := storage.NewLocal(base, nil)
+	if err := os.MkdirAll(filepath.Join(base, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "docs", "old.md"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	author := models.User{ID: 7, Username: "admin"}
+	if _, err := ImportMarkdownTree(context.Background(), db, files, author); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var post models.Post
+	if err := db.Where("source_path = ?", "docs/old.md").First(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 保留期内源文件不动，彻底删除后源文件随之删除
+	if _, err := PurgeSoftDeleted(context.Background(), db, files, time.Hour); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "docs", "old.md")); err != nil {
+		t.Fatalf("expected source to be kept during retention, got %v", err)
+	}
+	if _, err := PurgeSoftDeleted(context.Background(), db, files, 0); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "docs", "old.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected source to be removed, got %v", err)
+	}
+	result, err := ImportMarkdownTree(context.Background(), db, files, author)
+	if err != nil || result.Imported != 0 {
+		t.Fatalf("expected nothing to be re-imported, got %+v %v", result, err)
+	}
+}
+
+func TestPublishDuePosts(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	due := models.Post{Title: "due", Slug: "due"}
+	due.Schedule(now.Add(-time.Minute))
+	later := models.Post{Title: "later", Slug: "later"}
+	later.Schedule(now.Add(time.Hour))
+	draft := models.Post{Title: "draft", Slug: "draft", Status: models.PostDraft}
+	for _, p := range []*models.Post{&due, &later, &draft} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	published, err := PublishDuePosts(context.Background(), db, now)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(published) != 1 || published[0].ID != due.ID {
+		t.Fatalf("expected only the due post to be published, got %+v", published)
+	}
+
+	var got models.Post
+	if err := db.First(&got, due.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.PostPublished || got.PublishAt != nil || got.PublishedAt == nil || !got.PublishedAt.Equal(now.Add(-time.Minute)) {
+		t.Errorf("unexpected published post %+v", got)
+	}
+	var pending models.Post
+	if err := db.First(&pending, later.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if pending.Status != models.PostScheduled {
+		t.Errorf("expected future post to stay scheduled, got %s", pending.Status)
+	}
+}
+
+func TestPublishDuePostsSkipsWithdrawn(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	withdrawn := models.Post{Title: "withdrawn", Slug: "withdrawn"}
+	withdrawn.Schedule(now.Add(-time.Minute))
+	if err := db.Create(&withdrawn).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟加载定时文章后、更新前管理员把文章撤回为草稿
+	err := db.Callback().Query().After("gorm:query").Register("test:withdraw", func(tx *gorm.DB) {
+		tx.Session(&gorm.Session{NewDB: true}).Model(&models.Post{}).
+			Where("id = ?", withdrawn.ID).Updates(map[string]interface{}{"status": models.PostDraft, "publish_at": nil})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published, err := PublishDuePosts(context.Background(), db, now)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(published) != 0 {
+		t.Fatalf("expected withdrawn post not to be reported, got %+v", published)
+	}
+	if err := db.Callback().Query().Remove("test:withdraw"); err != nil {
+		t.Fatal(err)
+	}
+	var got models.Post
+	if err := db.First(&got, withdrawn.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.PostDraft {
+		t.Errorf("expected post to stay a draft, got %s", got.Status)
+	}
+}
+
+func TestPurgeKeepsSourceOfLivePost(t *testing.T) {
+	db := openTestDB(t)
+	base := t.TempDir()
+	files := storage.NewLocal(base, nil)
+	if err := files.Put(context.Background(), "docs/shared.md", strings.NewReader("shared"), 6, ""); err != nil {
+		t.Fatal(err)
+	}
+	deleted := models.Post{Title: "old", Slug: "old", Folder: "docs", SourcePath: "docs/shared.md", Status: models.PostPublished}
+	live := models.Post{Title: "new", Slug: "new", Folder: "docs", SourcePath: "docs/shared.md", Status: models.PostPublished}
+	for _, p := range []*models.Post{&deleted, &live} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PurgeSoftDeleted(context.Background(), db, files, 0); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if _, err := files.Stat(context.Background(), "docs/shared.md"); err != nil {
+		t.Fatalf("expected source of live post to be kept, got %v", err)
+	}
+}
+
+func TestSavePostMovesFileAfterCommit(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	files := storage.NewLocal(t.TempDir(), nil)
+	if err := files.Put(ctx, "docs/a.md", strings.NewReader("a"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	taken := models.Post{Title: "taken", Slug: "taken", Status: models.PostDraft}
+	post := models.Post{Title: "a", Slug: "a", Folder: "docs", SourcePath: "docs/a.md", Status: models.PostDraft}
+	for _, p := range []*models.Post{&taken, &post} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	editor := models.User{ID: 1, Username: "admin"}
+	exists := func(key string) bool {
+		_, err := files.Stat(ctx, key)
+		return err == nil
+	}
+
+	// 保存失败时文件和文件路径都不变
+	failed := post
+	failed.Slug, failed.Folder, failed.SourcePath = "taken", "archive", "archive/a.md"
+	if err := SavePost(db, files, &failed, editor, SaveOptions{MoveFrom: "docs/a.md"}); err == nil {
+		t.Fatal("expected duplicate slug to fail")
+	}
+	var got models.Post
+	if err := db.First(&got, post.ID).Error; err != nil || got.SourcePath != "docs/a.md" {
+		t.Fatalf("expected source path to be unchanged, got %+v %v", got, err)
+	}
+	if !exists("docs/a.md") || exists("archive/a.md") {
+		t.Fatal("expected file to stay in place after a failed save")
+	}
+
+	moved := post
+	moved.Folder, moved.SourcePath = "archive", "archive/a.md"
+	if err := SavePost(db, files, &moved, editor, SaveOptions{MoveFrom: "docs/a.md"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if exists("docs/a.md") || !exists("archive/a.md") {
+		t.Fatal("expected file to be moved after a successful save")
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"repair-platform/frontmatter"
+	"repair-platform/models"
+	"repair-platform/storage"
+
+	"gorm.io/gorm"
+)
+
+// SaveOptions 保存文章时的可选参数
+type SaveOptions struct {
+	// Content 写入 Markdown 文件的原始内容，为空时根据文章生成；文章不是从文件导入的时忽略
+	Content []byte
+	// RollbackOf 由回滚产生的保存，记录被恢复的版本号
+	RollbackOf int
+	// Restore 同时恢复已软删除的文章，用于覆盖上传已删除文章的文件
+	Restore bool
+	// MoveFrom 文章原来的文件路径，与 SourcePath 不同时文件写入新路径，保存成功后删除原文件
+	MoveFrom string
+}
+
+// SavePost 在一个事务中保存文章、记录版本，并同步 files 中对应的 Markdown 文件
+// 文件写入失败时数据库修改一并回滚；移动文件时新路径已存在返回 ErrFileExists，保存失败时原文件保持不变
+func SavePost(db *gorm.DB, files storage.Storage, post *models.Post, editor models.User, opts SaveOptions) error {
+	ctx := db.Statement.Context
+	moving := opts.MoveFrom != "" && opts.MoveFrom != post.SourcePath
+	if moving {
+		if _, err := files.Stat(ctx, post.SourcePath); err == nil {
+			return ErrFileExists
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	err := savePost(db, files, post, editor, opts)
+	switch {
+	case err != nil && moving:
+		// 事务提交失败时新文件可能已经写入
+		if derr := files.Delete(ctx, post.SourcePath); derr != nil {
+			return errors.Join(err, derr)
+		}
+		return err
+	case err != nil:
+		return err
+	case moving:
+		if err := files.Delete(ctx, opts.MoveFrom); err != nil {
+			return fmt.Errorf("failed to remove moved file %s: %w", opts.MoveFrom, err)
+		}
+	}
+	return nil
+}
+
+// savePost 执行 SavePost 的事务部分
+func savePost(db *gorm.DB, files storage.Storage, post *models.Post, editor models.User, opts SaveOptions) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		save := tx
+		if opts.Restore {
+			post.DeletedAt = gorm.DeletedAt{}
+			save = tx.Unscoped()
+		}
+		if err := save.Save(post).Error; err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+		if _, err := RecordRevision(tx, post, editor, opts.RollbackOf); err != nil {
+			return err
+		}
+		return WritePostFile(db.Statement.Context, files, post, opts.Content)
+	})
+}
+
+// RecordRevision 将文章当前内容保存为新版本，内容与最新版本相同且不是回滚时不记录，返回 nil
+func RecordRevision(db *gorm.DB, post *models.Post, editor models.User, rollbackOf int) (*models.PostRevision, error) {
+	revision := models.NewPostRevision(post, editor)
+	revision.RollbackOf = rollbackOf
+
+	var latest models.PostRevision
+	err := db.Where("post_id = ?", post.ID).Order("number DESC").First(&latest).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		revision.Number = 1
+	case err != nil:
+		return nil, fmt.Errorf("failed to load latest revision: %w", err)
+	case rollbackOf == 0 && latest.SameContent(&revision):
+		return nil, nil
+	default:
+		revision.Number = latest.Number + 1
+	}
+
+	if err := db.Create(&revision).Error; err != nil {
+		return nil, fmt.Errorf("failed to save revision: %w", err)
+	}
+	return &revision, nil
+}
+
+// WritePostFile 将文章写回导入时的 Markdown 文件，content 为空时用文章字段生成 front matter
+// 没有对应文件的文章不做任何事
+func WritePostFile(ctx context.Context, files storage.Storage, post *models.Post, content []byte) error {
+	if post.SourcePath == "" {
+		return nil
+	}
+	if content == nil {
+		var err error
+		if content, err = frontmatter.Format(PostMeta(post), []byte(post.Body)); err != nil {
+			return fmt.Errorf("failed to format front matter: %w", err)
+		}
+	}
+	return files.Put(ctx, post.SourcePath, bytes.NewReader(content), int64(len(content)), "text/markdown; charset=utf-8")
+}
+
+// PostMeta 生成文章对应的 front matter，未发布的文章标记为草稿，定时发布的文章以发布时间作为日期
+func PostMeta(post *models.Post) *frontmatter.Meta {
+	meta := &frontmatter.Meta{
+		Title:   post.Title,
+		Tags:    post.Tags,
+		Author:  post.Author,
+		Summary: post.Summary,
+		Cover:   post.Cover,
+	}
+	switch post.Status {
+	case models.PostPublished:
+		meta.Date = post.PublishedAt
+	case models.PostScheduled:
+		meta.Date = post.PublishAt
+	default:
+		meta.Draft = true
+		meta.Date = post.PublishedAt
+	}
+	return meta
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"repair-platform/i18n"
+	"repair-platform/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SLAChecker 检查超时未处理的报修请求并通知管理员
+type SLAChecker struct {
+	db        *gorm.DB
+	mailer    EmailService
+	threshold time.Duration
+	interval  time.Duration
+	logger    *zap.SugaredLogger
+}
+
+// NewSLAChecker 创建 SLA 检查器，threshold 为待处理状态允许停留的最长时间，interval 为检查间隔
+func NewSLAChecker(db *gorm.DB, mailer EmailService, threshold, interval time.Duration, logger *zap.SugaredLogger) *SLAChecker {
+	return &SLAChecker{
+		db:        db,
+		mailer:    mailer,
+		threshold: threshold,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Check 找出在上一个检查周期内刚超时的报修请求，每个请求只通知一次
+func (s *SLAChecker) Check(ctx context.Context) error {
+	deadline := time.Now().Add(-s.threshold)
+
+	var overdue []models.RepairRequest
+	err := s.db.WithContext(ctx).
+		Where("status IN ?", []string{"", models.StatusPending}).
+		Where("created_at <= ? AND created_at > ?", deadline, deadline.Add(-s.interval)).
+		Order("id").
+		Find(&overdue).Error
+	if err != nil {
+		return fmt.Errorf("failed to query overdue repair requests: %w", err)
+	}
+	if len(overdue) == 0 {
+		return nil
+	}
+
+	var lines []string
+	for _, r := range overdue {
+		s.logger.Warnf("报修请求超时未处理, ID: %d, 优先级: %s, 提交时间: %s", r.ID, r.Priority, r.CreatedAt.Format(time.RFC3339))
+		lines = append(lines, fmt.Sprintf("#%d [%s] %s (%s)", r.ID, r.Priority, r.Description, r.CreatedAt.Format("2006-01-02 15:04")))
+	}
+
+	var admins []models.User
+	if err := s.db.WithContext(ctx).Where("role = ? AND is_verified = ?", models.RoleAdmin, true).Find(&admins).Error; err != nil {
+		return fmt.Errorf("failed to query admins: %w", err)
+	}
+
+	// 按每个管理员的语言偏好生成邮件
+	list := strings.Join(lines, "\n")
+	for _, admin := range admins {
+		locale, ok := i18n.Parse(admin.Locale)
+		if !ok {
+			locale = i18n.Default
+		}
+		subject := i18n.T(locale, "mail.sla_overdue.subject", len(overdue), s.threshold)
+		body := i18n.T(locale, "mail.sla_overdue.body", list)
+		if err := s.mailer.SendMail(admin.Email, subject, body); err != nil {
+			s.logger.Errorf("发送 SLA 通知给 %s 失败: %v", admin.Email, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"repair-platform/models"
+
+	"gorm.io/gorm"
+)
+
+// TagCount 标签及使用该标签的已发布文章数
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// PublishedTags 统计已发布文章的标签，按文章数降序、标签名升序排列
+func PublishedTags(db *gorm.DB) ([]TagCount, error) {
+	var posts []models.Post
+	if err := db.Select("tags").Where("status = ?", models.PostPublished).Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, p := range posts {
+		for _, tag := range p.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
+}
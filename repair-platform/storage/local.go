@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Local 将对象保存为 dir 下的文件，key 即相对路径；文件系统不保存内容类型，读取时按扩展名推断
+type Local struct {
+	dir    string
+	signer *Signer
+}
+
+// NewLocal 创建以 dir 为根目录的本地存储，signer 为 nil 时不能签发下载地址
+func NewLocal(dir string, signer *Signer) *Local {
+	return &Local{dir: dir, signer: signer}
+}
+
+// Put 先写入同目录下的临时文件再替换，失败时原文件保持不变
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get 打开文件
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, Object{}, notFound(key, err)
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, Object{}, notFound(key, err)
+	}
+	return f, localObject(key, info), nil
+}
+
+// Stat 返回文件信息，目录视为不存在
+func (l *Local) Stat(ctx context.Context, key string) (Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		return Object{}, notFound(key, err)
+	}
+	return localObject(key, info), nil
+}
+
+// Delete 删除文件，不删除空出来的目录
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List 遍历 prefix 所在的目录，跳过以 . 开头的文件（包括写入中的临时文件）
+func (l *Local) List(ctx context.Context, prefix string) ([]Object, error) {
+	root := l.dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		if !ValidKey(prefix[:i]) {
+			return nil, ErrInvalidKey
+		}
+		root = filepath.Join(l.dir, filepath.FromSlash(prefix[:i]))
+	}
+	if !IsInside(root, l.dir) {
+		return nil, ErrInvalidKey
+	}
+
+	objects := []Object{}
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, localObject(key, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// 以下目录操作只有本地存储支持，供以目录树作为分类的 Markdown 目录使用，key 为空表示根目录
+
+// Contains 判断 key 是否为合法且不会经由符号链接跳出根目录的路径
+func (l *Local) Contains(key string) bool {
+	_, err := l.path(key)
+	return err == nil
+}
+
+// MkdirAll 创建目录 key 及其上级目录
+func (l *Local) MkdirAll(key string) error {
+	p, err := l.dirPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p, 0o755); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", key, err)
+	}
+	return nil
+}
+
+// ReadDir 返回目录 key 中按名称排序的条目，目录不存在时返回 ErrNotFound
+func (l *Local) ReadDir(key string) ([]fs.DirEntry, error) {
+	p, err := l.dirPath(key)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, notFound(key, err)
+	}
+	return entries, nil
+}
+
+// Exists 判断 key 对应的文件或目录是否存在
+func (l *Local) Exists(key string) (bool, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Rename 将文件或目录从 from 移动到 to，自动创建 to 的上级目录
+func (l *Local) Rename(from, to string) error {
+	src, err := l.path(from)
+	if err != nil {
+		return err
+	}
+	dst, err := l.path(to)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return nil
+}
+
+// RemoveAll 删除目录 key 及其中的全部内容，不能删除根目录
+func (l *Local) RemoveAll(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(p); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// Walk 按名称顺序遍历根目录下的全部文件和目录（不含根目录本身），根目录不存在时不做任何事
+// fn 收到以 / 分隔的相对路径，对目录返回 fs.SkipDir 时跳过该目录
+func (l *Local) Walk(ctx context.Context, fn func(key string, entry fs.DirEntry) error) error {
+	err := filepath.WalkDir(l.dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == l.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p == l.dir {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", l.dir, err)
+	}
+	return nil
+}
+
+// SignedURL 返回由 GET /api/files/*key 提供下载的签名地址
+func (l *Local) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	if l.signer == nil {
+		return "", ErrSignedURLDisabled
+	}
+	return l.signer.URL(key, ttl), nil
+}
+
+// VerifySignedURL 实现 Verifier
+func (l *Local) VerifySignedURL(key, expires, signature string) error {
+	if l.signer == nil {
+		return ErrSignedURLDisabled
+	}
+	return l.signer.Verify(key, expires, signature)
+}
+
+// path 返回 key 对应的文件路径，经由符号链接跳出根目录的 key 视为非法
+func (l *Local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	p := filepath.Join(l.dir, filepath.FromSlash(key))
+	if !IsInside(p, l.dir) {
+		return "", ErrInvalidKey
+	}
+	return p, nil
+}
+
+// dirPath 与 path 相同，但 key 为空时返回根目录
+func (l *Local) dirPath(key string) (string, error) {
+	if key == "" {
+		return l.dir, nil
+	}
+	return l.path(key)
+}
+
+// localObject 将文件信息转换为对象元数据
+func localObject(key string, info fs.FileInfo) Object {
+	return Object{Key: key, Size: info.Size(), ContentType: detectContentType(key, ""), ModTime: info.ModTime()}
+}
+
+// notFound 文件或目录不存在时返回 ErrNotFound，其他错误原样包装
+func notFound(key string, err error) error {
+	if err == nil || os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("failed to stat %s: %w", key, err)
+}
+
+// IsInside 判断 p 是否在 base 内（可以是 base 本身）
+// 按路径分段比较，避免 /data/md-other 被当作 /data/md 的子路径；已存在的部分先解析符号链接，防止经由链接跳出 base
+func IsInside(p, base string) bool {
+	absBase, err := resolvePath(base)
+	if err != nil {
+		return false
+	}
+	absPath, err := resolvePath(p)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolvePath 返回绝对路径，路径中已存在的最长前缀解析符号链接，其余部分原样拼接
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	existing, rest := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
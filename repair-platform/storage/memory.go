@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory 将对象保存在内存中，用于测试；重启后内容丢失
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	signer  *Signer
+}
+
+// memoryObject 内存中的对象内容和元数据
+type memoryObject struct {
+	data []byte
+	meta Object
+}
+
+// NewMemory 创建空的内存存储，signer 为 nil 时不能签发下载地址
+func NewMemory(signer *Signer) *Memory {
+	return &Memory{objects: make(map[string]memoryObject), signer: signer}
+}
+
+// Put 读取全部内容后保存
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{
+		data: data,
+		meta: Object{Key: key, Size: int64(len(data)), ContentType: detectContentType(key, contentType), ModTime: time.Now()},
+	}
+	return nil
+}
+
+// Get 返回内容的副本，调用方修改不影响已保存的对象
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	obj, err := m.load(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), obj.meta, nil
+}
+
+// Stat 返回对象的元数据
+func (m *Memory) Stat(ctx context.Context, key string) (Object, error) {
+	obj, err := m.load(key)
+	return obj.meta, err
+}
+
+// Delete 删除对象
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// List 返回 key 以 prefix 开头的对象
+func (m *Memory) List(ctx context.Context, prefix string) ([]Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	objects := []Object{}
+	for key, obj := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, obj.meta)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// SignedURL 与本地存储相同，由 GET /api/files/*key 提供下载
+func (m *Memory) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	if m.signer == nil {
+		return "", ErrSignedURLDisabled
+	}
+	return m.signer.URL(key, ttl), nil
+}
+
+// VerifySignedURL 实现 Verifier
+func (m *Memory) VerifySignedURL(key, expires, signature string) error {
+	if m.signer == nil {
+		return ErrSignedURLDisabled
+	}
+	return m.signer.Verify(key, expires, signature)
+}
+
+// load 查找对象
+func (m *Memory) load(key string) (memoryObject, error) {
+	if !ValidKey(key) {
+		return memoryObject{}, ErrInvalidKey
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"repair-platform/config"
+	"repair-platform/tracing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 将对象保存在 S3 兼容的对象存储（AWS S3、MinIO 等）的一个 bucket 中
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3 创建 S3 存储，bucket 需要事先创建；对象存储的请求会生成客户端 span
+func NewS3(cfg config.S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:    cfg.UseSSL,
+		Region:    cfg.Region,
+		Transport: tracing.HTTPClient(0).Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+	return &S3{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put 上传对象，超过单次上传大小时由客户端自动分片
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: detectContentType(key, contentType)})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get 下载对象，先获取元数据以便对象不存在时立即返回 ErrNotFound
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	if !ValidKey(key) {
+		return nil, Object{}, ErrInvalidKey
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, Object{}, s3Error(key, err)
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, Object{}, s3Error(key, err)
+	}
+	return obj, s3Object(info), nil
+}
+
+// Stat 返回对象的元数据
+func (s *S3) Stat(ctx context.Context, key string) (Object, error) {
+	if !ValidKey(key) {
+		return Object{}, ErrInvalidKey
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return Object{}, s3Error(key, err)
+	}
+	return s3Object(info), nil
+}
+
+// Delete 删除对象
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List 列出 prefix 下的全部对象，包括“子目录”中的对象
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, info.Err)
+		}
+		objects = append(objects, s3Object(info))
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// SignedURL 返回对象存储的预签名下载地址，S3 限制有效期最长 7 天
+func (s *S3) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+// s3Object 将对象存储返回的信息转换为对象元数据
+func s3Object(info minio.ObjectInfo) Object {
+	return Object{Key: info.Key, Size: info.Size, ContentType: detectContentType(info.Key, info.ContentType), ModTime: info.LastModified}
+}
+
+// s3Error 对象不存在时返回 ErrNotFound，其他错误原样包装
+func s3Error(key string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return fmt.Errorf("failed to read %s: %w", key, err)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedPathPrefix 本地和内存后端签发的下载地址的路径前缀，对应 GET /api/files/*key
+const SignedPathPrefix = "/api/files/"
+
+// Signer 使用 HMAC-SHA256 签发和校验带有效期的下载地址
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewSigner 使用 secret 创建签名器
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret), now: time.Now}
+}
+
+// URL 返回 key 在 ttl 内有效的下载地址（站内相对路径）
+func (s *Signer) URL(key string, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return SignedPathPrefix + EscapeKey(key) + "?" + query.Encode()
+}
+
+// Verify 校验下载地址中的过期时间和签名
+func (s *Signer) Verify(key, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrSignatureInvalid
+	}
+	if s.now().Unix() > unix {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// sign 计算 key 和过期时间的签名
+func (s *Signer) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EscapeKey 逐段转义 key，用于拼接 URL
+func EscapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,92 @@
+// Package storage 提供上传文件的存储后端：本地文件系统、S3 兼容对象存储和用于测试的内存存储
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"repair-platform/config"
+)
+
+// 存储操作失败的原因
+var (
+	ErrNotFound          = errors.New("object not found")
+	ErrInvalidKey        = errors.New("invalid object key")
+	ErrSignedURLDisabled = errors.New("signed urls are not configured")
+	ErrSignatureInvalid  = errors.New("invalid signature")
+	ErrSignatureExpired  = errors.New("signed url has expired")
+)
+
+// Object 存储对象的元数据
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// Storage 按 key 存取文件，key 为以 / 分隔的相对路径，不能包含空段、. 和 ..
+type Storage interface {
+	// Put 写入 size 字节的对象，已存在时覆盖；contentType 为空时按扩展名推断
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser；对象不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Stat 返回对象的元数据，对象不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// List 返回 key 以 prefix 开头的全部对象，按 key 排序
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL 返回在 ttl 内无需登录即可下载对象的地址
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// Verifier 由自行签发下载地址的后端（本地和内存）实现，S3 的预签名地址由对象存储校验
+type Verifier interface {
+	// VerifySignedURL 校验下载地址中的 expires 和 signature 参数
+	VerifySignedURL(key, expires, signature string) error
+}
+
+// New 按配置创建存储后端，本地后端以 dir 为根目录，signer 用于本地和内存后端签发下载地址
+func New(cfg config.StorageConfig, dir string, signer *Signer) (Storage, error) {
+	switch cfg.Backend {
+	case config.StorageLocal:
+		return NewLocal(dir, signer), nil
+	case config.StorageS3:
+		return NewS3(cfg.S3)
+	case config.StorageMemory:
+		return NewMemory(signer), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.Backend)
+	}
+}
+
+// ValidKey 判断 key 是否为合法的相对路径
+func ValidKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, "\\\x00") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// detectContentType 未记录内容类型时按扩展名推断
+func detectContentType(key, fallback string) string {
+	if fallback != "" {
+		return fallback
+	}
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBackend 各后端共同的行为
+func testBackend(t *testing.T, s Storage) {
+	ctx := context.Background()
+	put := func(key, body string) {
+		t.Helper()
+		if err := s.Put(ctx, key, strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	put("docs/a.txt", "hello")
+	put("docs/sub/b.txt", "world!")
+	put("other.txt", "x")
+
+	obj, err := s.Stat(ctx, "docs/a.txt")
+	if err != nil || obj.Size != 5 || obj.Key != "docs/a.txt" || !strings.HasPrefix(obj.ContentType, "text/plain") {
+		t.Fatalf("stat: %+v %v", obj, err)
+	}
+	r, obj, err := s.Get(ctx, "docs/sub/b.txt")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	body, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(body) != "world!" || obj.Size != 6 {
+		t.Fatalf("get: %q %+v %v", body, obj, err)
+	}
+
+	// 覆盖已有对象
+	put("docs/a.txt", "hello again")
+	if obj, err := s.Stat(ctx, "docs/a.txt"); err != nil || obj.Size != 11 {
+		t.Fatalf("overwrite: %+v %v", obj, err)
+	}
+
+	list, err := s.List(ctx, "docs/")
+	if err != nil || len(list) != 2 || list[0].Key != "docs/a.txt" || list[1].Key != "docs/sub/b.txt" {
+		t.Fatalf("list docs/: %+v %v", list, err)
+	}
+	if list, err := s.List(ctx, ""); err != nil || len(list) != 3 {
+		t.Fatalf("list all: %+v %v", list, err)
+	}
+	if list, err := s.List(ctx, "missing/"); err != nil || len(list) != 0 {
+		t.Fatalf("list missing: %+v %v", list, err)
+	}
+
+	if err := s.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := s.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("delete missing: %v", err)
+	}
+	if _, err := s.Stat(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat deleted: %v", err)
+	}
+	if _, _, err := s.Get(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get deleted: %v", err)
+	}
+
+	for _, key := range []string{"", "../x", "docs/../x", "/abs", "docs//b.txt", `docs\b.txt`} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("put %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+
+	u, err := s.SignedURL(ctx, "docs/sub/b.txt", time.Minute)
+	if err != nil || u == "" {
+		t.Fatalf("signed url: %q %v", u, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testBackend(t, NewMemory(NewSigner("secret")))
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	testBackend(t, NewLocal(dir, NewSigner("secret")))
+
+	// 经由符号链接跳出根目录的 key 不可用
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+	s := NewLocal(dir, nil)
+	if _, err := s.Stat(context.Background(), "link/secret.txt"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := s.SignedURL(context.Background(), "other.txt", time.Minute); !errors.Is(err, ErrSignedURLDisabled) {
+		t.Fatalf("expected ErrSignedURLDisabled, got %v", err)
+	}
+}
+
+func TestLocalDirectories(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(filepath.Join(t.TempDir(), "md"), nil)
+
+	// 根目录不存在时遍历不做任何事
+	if err := s.Walk(ctx, func(string, fs.DirEntry) error { return errors.New("unexpected entry") }); err != nil {
+		t.Fatalf("walk missing root: %v", err)
+	}
+	if _, err := s.ReadDir("docs"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.MkdirAll("docs/empty"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "docs/a.md", strings.NewReader("a"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rename("docs", "archive/docs"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if ok, err := s.Exists("docs"); err != nil || ok {
+		t.Fatalf("expected docs to be moved: %v %v", ok, err)
+	}
+
+	var keys []string
+	err := s.Walk(ctx, func(key string, entry fs.DirEntry) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil || strings.Join(keys, ",") != "archive,archive/docs,archive/docs/a.md,archive/docs/empty" {
+		t.Fatalf("walk: %v %v", keys, err)
+	}
+	if entries, err := s.ReadDir("archive/docs"); err != nil || len(entries) != 2 {
+		t.Fatalf("read dir: %v %v", entries, err)
+	}
+	if err := s.RemoveAll("archive"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := s.ReadDir(""); err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty root, got %v %v", entries, err)
+	}
+	if s.Contains("../outside") || s.RemoveAll("") == nil {
+		t.Fatal("expected keys outside the root to be rejected")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_800_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	raw := s.URL("images/照片 1.png", time.Minute)
+	u, err := url.Parse(raw)
+	if err != nil || u.Path != SignedPathPrefix+"images/照片 1.png" {
+		t.Fatalf("url: %q %v", raw, err)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if err := s.Verify("images/照片 1.png", expires, signature); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := s.Verify("images/other.png", expires, signature); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("other key: %v", err)
+	}
+	if err := NewSigner("other").Verify("images/照片 1.png", expires, signature); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("other secret: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := s.Verify("images/照片 1.png", expires, signature); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expired: %v", err)
+	}
+}